@@ -0,0 +1,371 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dd-web/pgsvk-seeder/pkg/types"
+)
+
+var usage = `usage: seeder <command> [flags]
+
+commands:
+  migrate up          run pending up migrations in order, -n limits how many
+  migrate down        roll back applied migrations in reverse order, -n limits how many
+  migrate to <v>      migrate up or down until version v is the last applied migration
+  migrate finalize    run the pending transatory phases (keys and identity columns)
+  migrate unfinalize  undo the transatory phases so the schema can be seeded again
+  migrate status      list every migration and whether it was applied and finalized
+  seed                seed the database and run the defered (transatory) migrations,
+                      -reseed clears an already seeded database first, -resume
+                      continues an interrupted seed, -introspect seeds whatever
+                      schema the live database has instead
+  introspect          print the tables of the live database in the order they're seeded
+  reset               roll back, migrate up, seed and finalize in one go
+  stats               print the number of rows in each seeded table
+  schema              print the scripts of a migration phase generated from the schema
+                      description, -phase up, down, transatory or transatory_down. -write
+                      generates every script of a migration, -check fails when they differ
+
+run "seeder <command> -h" to list the flags of a command
+`
+
+// count range flags and the seeder config function each of them maps to
+var count_flags = map[string]func(int) types.SeederConfigFunc{
+	"min-accounts": types.SeederCfgSetMinAccountCount,
+	"max-accounts": types.SeederCfgSetMaxAccountCount,
+	"min-articles": types.SeederCfgSetMinArticleCount,
+	"max-articles": types.SeederCfgSetMaxArticleCount,
+	"min-threads":  types.SeederCfgSetMinThreadPerBoard,
+	"max-threads":  types.SeederCfgSetMaxThreadPerBoard,
+	"min-posts":    types.SeederCfgSetMinPostPerThread,
+	"max-posts":    types.SeederCfgSetMaxPostPerThread,
+}
+
+// options collected from the command line. only flags that were explicitly set are turned into
+// config functions, everything else falls back to the PG* environment variables and then the
+// defaults of the types package.
+type cliOptions struct {
+	migrationPath string
+	steps         int
+	reseed        bool
+	resume        bool
+	checkpoint    string
+	seeded        bool // the seed was given, by -seed or the profile
+	introspect    bool
+	schema        string
+
+	pg           []types.PGConfigFunc
+	seeder       []types.SeederConfigFunc
+	schemaSeeder []types.SchemaSeederConfigFunc
+}
+
+// creates a flag set for the given command with the connection flags and, if seeding is
+// involved, the seeder flags as well
+func newFlagSet(name string, seeding bool) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	fs.String("migrations", migration_path, "path to the migrations directory")
+
+	if name == "migrate up" || name == "migrate down" {
+		fs.Int("n", 0, "number of migrations to apply or roll back, 0 for all of them")
+	}
+
+	fs.String("url", "", "postgres:// connection url, individual connection flags override its fields")
+	fs.String("host", "", "database host")
+	fs.String("port", "", "database port")
+	fs.String("user", "", "database user")
+	fs.String("password", "", "database password")
+	fs.String("dbname", "", "database name")
+	fs.String("sslmode", "", "database sslmode")
+	fs.String("connfmt", "", "connection string format (user, password, dbname, host, port, sslmode), given the values quoted")
+
+	if name == "seed" {
+		fs.Bool("reseed", false, "undo the transatory phase and clear the seeded tables of an already seeded schema first")
+		fs.Bool("resume", false, "continue an interrupted seed, keeping the rows it already committed")
+		fs.Bool("introspect", false, "read the schema from the database and fill its empty tables instead of seeding opforu")
+		fs.Int("rows", 0, "number of rows per table when seeding an introspected schema")
+	}
+
+	if name == "seed" || name == "introspect" {
+		fs.String("schema", "public", "postgres schema to introspect")
+	}
+
+	if seeding {
+		fs.Int64("seed", 0, "seed of the random source, the same seed and time window (the default one included) reproduce the same dataset")
+		fs.String("preset", "", "size of the dataset: "+strings.Join(types.PresetNames(), ", ")+", the profile and flags override it")
+		fs.String("profile", "", "JSON file describing the dataset, flags override what it sets")
+		fs.Int("workers", 0, "number of tables inserted into at once, 1 inserts them one after the other")
+		fs.String("checkpoint", "", "file the progress of the seed is written to after every chunk, none by default")
+		fs.Int("batch-size", 0, "rows copied and committed per transaction, 0 for one transaction per table")
+		fs.String("since", "", "start of the generated activity, a date, an RFC 3339 time or how long before -until (2y, 90d, 36h), 2y by default")
+		fs.String("until", "", "end of the generated activity, a date or an RFC 3339 time, 2025-01-01 by default")
+		fs.String("traffic", "", "when threads and replies are posted: "+strings.Join(types.TrafficModelNames(), ", ")+", forum by default")
+		fs.Float64("burst-rate", 0, "share of threads that go viral and get a burst of replies")
+		fs.String("delete-rate", "", "share of soft-deleted rows per table, as accounts=0.1,posts=0.05")
+		fs.String("popularity", "", "how posts are spread over the accounts, threads and boards, as accounts=zipf:1.2,threads=pareto:1.5,boards=uniform")
+		fs.Bool("stream", false, "stream posts into the database while they're generated instead of holding them in memory")
+		fs.Int("min-accounts", 0, "minimum number of generated accounts")
+		fs.Int("max-accounts", 0, "maximum number of generated accounts")
+		fs.Int("min-articles", 0, "minimum number of generated articles")
+		fs.Int("max-articles", 0, "maximum number of generated articles")
+		fs.Int("min-threads", 0, "minimum number of threads per board")
+		fs.Int("max-threads", 0, "maximum number of threads per board")
+		fs.Int("min-posts", 0, "minimum average of replies per thread of a board, the popularity of the threads spreads them")
+		fs.Int("max-posts", 0, "maximum average of replies per thread of a board, a single thread can get more")
+	}
+
+	return fs
+}
+
+// parses the arguments with the given flag set and turns the flags that were set into options
+func parseOptions(fs *flag.FlagSet, args []string) (*cliOptions, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	opts := &cliOptions{
+		migrationPath: fs.Lookup("migrations").Value.String(),
+	}
+
+	if f := fs.Lookup("n"); f != nil {
+		opts.steps = f.Value.(flag.Getter).Get().(int)
+	}
+
+	if f := fs.Lookup("reseed"); f != nil {
+		opts.reseed = f.Value.(flag.Getter).Get().(bool)
+	}
+
+	if f := fs.Lookup("resume"); f != nil {
+		opts.resume = f.Value.(flag.Getter).Get().(bool)
+	}
+
+	if f := fs.Lookup("checkpoint"); f != nil && f.Value.String() != "" {
+		opts.checkpoint = f.Value.String()
+		opts.seeder = append(opts.seeder, types.SeederCfgSetCheckpoint(opts.checkpoint))
+	}
+
+	if opts.reseed && opts.resume {
+		return nil, fmt.Errorf("-reseed and -resume can't be used together")
+	}
+
+	if f := fs.Lookup("introspect"); f != nil {
+		opts.introspect = f.Value.(flag.Getter).Get().(bool)
+	}
+
+	if f := fs.Lookup("schema"); f != nil {
+		opts.schema = f.Value.String()
+	}
+
+	// the preset, the profile and the traffic model go before the flags visited below so they
+	// override them
+	if f := fs.Lookup("preset"); f != nil && f.Value.String() != "" {
+		preset, err := types.ParsePreset(f.Value.String())
+		if err != nil {
+			return nil, err
+		}
+		opts.seeder = append(opts.seeder, types.SeederCfgSetPreset(preset))
+	}
+
+	if f := fs.Lookup("profile"); f != nil && f.Value.String() != "" {
+		profile, err := types.LoadProfile(f.Value.String())
+		if err != nil {
+			return nil, err
+		}
+		opts.seeder = append(opts.seeder, types.SeederCfgSetProfile(profile))
+		opts.seeded = profile.Seed != nil
+	}
+
+	if f := fs.Lookup("traffic"); f != nil && f.Value.String() != "" {
+		traffic, err := types.TrafficModel(f.Value.String())
+		if err != nil {
+			return nil, err
+		}
+		opts.seeder = append(opts.seeder, types.SeederCfgSetTraffic(traffic))
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		err = opts.apply(f)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// without a checkpoint the data is generated again from the seed, a new one would insert rows
+	// of a different dataset after the ones already committed
+	if opts.resume && opts.checkpoint == "" && !opts.seeded {
+		return nil, fmt.Errorf("-resume needs the -checkpoint or the -seed of the interrupted run")
+	}
+
+	if err := opts.timeWindow(fs); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// turns -since and -until into the time window of the seeder, a relative -since counts back from
+// -until
+func (o *cliOptions) timeWindow(fs *flag.FlagSet) error {
+	since, until := fs.Lookup("since"), fs.Lookup("until")
+	if since == nil || (since.Value.String() == "" && until.Value.String() == "") {
+		return nil
+	}
+
+	start, end := types.DefaultTimeWindow()
+	if v := until.Value.String(); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return fmt.Errorf("invalid value for -until: %v", err)
+		}
+		start, end = types.TimeWindowUntil(t)
+	}
+
+	if v := since.Value.String(); v != "" {
+		if d, err := parseAgo(v); err == nil {
+			start = end.Add(-d)
+		} else if t, err := parseTime(v); err == nil {
+			start = t
+		} else {
+			return fmt.Errorf("invalid value for -since: %q is neither a time nor a duration", v)
+		}
+	}
+
+	o.seeder = append(o.seeder, types.SeederCfgSetTimeWindow(start, end))
+	o.schemaSeeder = append(o.schemaSeeder, types.SchemaCfgSetTimeWindow(start, end))
+	return nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// a duration with y (365 days) and d (days) on top of the units time.ParseDuration knows
+func parseAgo(v string) (time.Duration, error) {
+	units := map[string]time.Duration{"y": 365 * 24 * time.Hour, "d": 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, err := strconv.Atoi(strings.TrimSuffix(v, suffix)); err == nil && strings.HasSuffix(v, suffix) {
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(v)
+}
+
+func (o *cliOptions) apply(f *flag.Flag) error {
+	value := f.Value.String()
+
+	switch f.Name {
+	case "url":
+		// flags are visited in lexical order, the url goes first so the other flags can override it
+		o.pg = append([]types.PGConfigFunc{types.PGCfgSetURL(value)}, o.pg...)
+	case "host":
+		o.pg = append(o.pg, types.PGCfgSetHost(value))
+	case "port":
+		o.pg = append(o.pg, types.PGCfgSetPort(value))
+	case "user":
+		o.pg = append(o.pg, types.PGCfgSetUser(value))
+	case "password":
+		o.pg = append(o.pg, types.PGCfgSetPass(value))
+	case "dbname":
+		o.pg = append(o.pg, types.PGCfgSetDBName(value))
+	case "sslmode":
+		o.pg = append(o.pg, types.PGCfgSetSSL(value))
+	case "connfmt":
+		o.pg = append(o.pg, types.PGCfgSetConnFmt(value))
+	case "seed":
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for -%s: %v", f.Name, err)
+		}
+		o.seeder = append(o.seeder, types.SeederCfgSetSeed(seed))
+		o.seeded = true
+		o.schemaSeeder = append(o.schemaSeeder, types.SchemaCfgSetSeed(seed))
+	case "workers":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for -%s: %v", f.Name, err)
+		}
+		o.seeder = append(o.seeder, types.SeederCfgSetWorkers(n))
+	case "batch-size":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for -%s: %v", f.Name, err)
+		}
+		o.seeder = append(o.seeder, types.SeederCfgSetBatchSize(n))
+	case "resume":
+		o.seeder = append(o.seeder, types.SeederCfgSetResume(value == "true"))
+	case "checkpoint":
+		// handled by parseOptions, an empty path writes no checkpoint
+	case "preset", "profile", "traffic":
+		// handled by parseOptions before any other flag
+	case "since", "until":
+		// handled by parseOptions once both are known
+	case "stream":
+		o.seeder = append(o.seeder, types.SeederCfgSetStreaming(value == "true"))
+	case "burst-rate":
+		r, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for -%s: %v", f.Name, err)
+		}
+		o.seeder = append(o.seeder, types.SeederCfgSetBurstRate(r))
+	case "delete-rate":
+		for _, pair := range strings.Split(value, ",") {
+			table, rate, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid value for -%s: %q is not table=rate", f.Name, pair)
+			}
+			r, err := strconv.ParseFloat(rate, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value for -%s: %v", f.Name, err)
+			}
+			o.seeder = append(o.seeder, types.SeederCfgSetDeleteRate(strings.TrimSpace(table), r))
+		}
+	case "popularity":
+		for _, pair := range strings.Split(value, ",") {
+			of, dist, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid value for -%s: %q is not accounts, threads or boards=distribution", f.Name, pair)
+			}
+			p, err := types.ParsePopularity(dist)
+			if err != nil {
+				return fmt.Errorf("invalid value for -%s: %v", f.Name, err)
+			}
+			o.seeder = append(o.seeder, types.SeederCfgSetPopularity(strings.TrimSpace(of), p))
+		}
+	case "rows":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for -%s: %v", f.Name, err)
+		}
+		o.schemaSeeder = append(o.schemaSeeder, types.SchemaCfgSetRows(n))
+	}
+
+	if setter, ok := count_flags[f.Name]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for -%s: %v", f.Name, err)
+		}
+		o.seeder = append(o.seeder, setter(n))
+	}
+
+	return nil
+}
+
+func printUsage() {
+	fmt.Fprint(os.Stderr, usage)
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
 	"time"
 
 	"github.com/dd-web/pgsvk-seeder/pkg/database"
@@ -10,85 +16,491 @@ import (
 )
 
 var (
-	migration_enable   = true
-	migration_path     = "./cmd/migrations"
-	migration_rollback = true
-
-	defered_migrations = map[int][]byte{}
+	migration_path = "./cmd/migrations"
 )
 
 func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	// an interrupt cancels seeding, the chunk being copied is rolled back and the checkpoint kept
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1], os.Args[2:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		stop()
+		log.Fatal(err)
+	}
+}
+
+// dispatches the given command with the remaining arguments
+func run(ctx context.Context, cmd string, args []string) error {
+	switch cmd {
+	case "migrate":
+		if len(args) < 1 {
+			return fmt.Errorf("migrate requires a direction: up, down, to, finalize, unfinalize or status")
+		}
+		switch args[0] {
+		case "up":
+			return cmdMigrateUp(args[1:])
+		case "down":
+			return cmdMigrateDown(args[1:])
+		case "status":
+			return cmdMigrateStatus(args[1:])
+		case "finalize":
+			return cmdMigrateFinalize(args[1:])
+		case "unfinalize":
+			return cmdMigrateUnfinalize(args[1:])
+		case "to":
+			if len(args) < 2 {
+				return fmt.Errorf("migrate to requires a version")
+			}
+			version, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid migration version: %s", args[1])
+			}
+			return cmdMigrateTo(version, args[2:])
+		default:
+			return fmt.Errorf("unknown migrate direction: %s", args[0])
+		}
+	case "seed":
+		return cmdSeed(ctx, args)
+	case "reset":
+		return cmdReset(ctx, args)
+	case "stats":
+		return cmdStats(args)
+	case "schema":
+		return cmdSchema(args)
+	case "introspect":
+		return cmdIntrospect(args)
+	case "help", "-h", "-help", "--help":
+		printUsage()
+		return nil
+	default:
+		printUsage()
+		return fmt.Errorf("unknown command: %s", cmd)
+	}
+}
+
+// parses the flags for the given command and connects to the database with them
+func setup(name string, seeding bool, args []string) (*cliOptions, *types.Store, error) {
+	opts, err := parseOptions(newFlagSet(name, seeding), args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store, err := types.NewStore(opts.pg...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return opts, store, nil
+}
+
+func cmdMigrateUp(args []string) error {
+	opts, store, err := setup("migrate up", false, args)
+	if err != nil {
+		return err
+	}
+	return migrate(store, opts.migrationPath, opts.steps, false)
+}
+
+func cmdMigrateDown(args []string) error {
+	opts, store, err := setup("migrate down", false, args)
+	if err != nil {
+		return err
+	}
+	return rollback(store, opts.migrationPath, opts.steps)
+}
+
+func cmdMigrateTo(version int, args []string) error {
+	opts, store, err := setup("migrate to", false, args)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := database.NewMigrator(store.DB, opts.migrationPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrating to %05d...\n", version)
+	if _, err := migrator.To(version); err != nil {
+		return err
+	}
+
+	fmt.Println("Migrations finished.")
+	return nil
+}
+
+func cmdMigrateStatus(args []string) error {
+	opts, store, err := setup("migrate status", false, args)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := database.NewMigrator(store.DB, opts.migrationPath)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(types.UnderlinePrint("Migrations"))
+	for _, ms := range statuses {
+		fmt.Printf("  %v\n", ms)
+	}
+	fmt.Printf("-------------------------\n")
+	return nil
+}
+
+func cmdMigrateFinalize(args []string) error {
+	opts, store, err := setup("migrate finalize", false, args)
+	if err != nil {
+		return err
+	}
+	return finalize(store, opts.migrationPath)
+}
+
+func cmdMigrateUnfinalize(args []string) error {
+	opts, store, err := setup("migrate unfinalize", false, args)
+	if err != nil {
+		return err
+	}
+	return unfinalize(store, opts.migrationPath)
+}
+
+func cmdSeed(ctx context.Context, args []string) error {
+	opts, store, err := setup("seed", true, args)
+	if err != nil {
+		return err
+	}
+
+	if opts.introspect {
+		return seedIntrospected(ctx, store, opts)
+	}
+
+	start := time.Now()
+	if err := prepareReseed(store, opts); err != nil {
+		return err
+	}
+	if err := prepareResume(opts); err != nil {
+		return err
+	}
+	if err := seed(ctx, store, opts); err != nil {
+		return err
+	}
+	fmt.Printf("Finished in %v\n\n", time.Since(start))
+	return nil
+}
+
+func cmdReset(ctx context.Context, args []string) error {
+	opts, store, err := setup("reset", true, args)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Starting...")
 	start := time.Now()
 
-	store, err := types.NewStore()
+	if err := migrate(store, opts.migrationPath, 0, true); err != nil {
+		return err
+	}
+	if err := seed(ctx, store, opts); err != nil {
+		return err
+	}
+
+	fmt.Printf("Finished in %v\n\n", time.Since(start))
+	return nil
+}
+
+func cmdStats(args []string) error {
+	_, store, err := setup("stats", false, args)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	fmt.Print(types.UnderlinePrint("Stats"))
+	for _, table := range types.SeedSchema().SeededTables() {
+		count, err := store.RowCount(table.Name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  - %v %v\n", count, table.Name)
+	}
+	fmt.Printf("-------------------------\n")
+	return nil
+}
+
+// prints the scripts of a migration phase generated from the schema description, or writes them
+// into a migration or checks the migration still matches them
+func cmdSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	phase := fs.String("phase", "up", "phase to print: up, down, transatory or transatory_down")
+	write := fs.String("write", "", "migration directory to write every generated script into")
+	check := fs.String("check", "", "migration directory whose scripts have to match the generated ones")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	schema := types.SeedSchema()
+	if err := schema.Validate(); err != nil {
+		return err
+	}
+
+	switch {
+	case *write != "":
+		return schema.WriteScripts(*write)
+	case *check != "":
+		if err := schema.CheckScripts(*check); err != nil {
+			return fmt.Errorf("%v, run \"schema -write %s\" after changing the description", err, *check)
+		}
+		fmt.Printf("%v matches the schema description\n", *check)
+		return nil
+	}
+
+	script, ok := schema.Scripts()[*phase+".sql"]
+	if !ok {
+		return fmt.Errorf("unknown phase: %s", *phase)
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// prints the tables of the live database in the order an introspected seed fills them, along with
+// the references and checks the generator has to keep
+func cmdIntrospect(args []string) error {
+	opts, store, err := setup("introspect", false, args)
+	if err != nil {
+		return err
+	}
+
+	seeder, err := types.IntrospectSeeder(store, opts.schema)
+	if err != nil {
+		return err
+	}
+
+	plan, err := seeder.Plan()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(types.UnderlinePrint("Seed Plan"))
+	for i, t := range plan {
+		fmt.Printf("  %d. %v\n", i+1, t.Name)
+		for _, c := range t.Columns {
+			fmt.Printf("       %v %v\n", c.Name, c.Type)
+		}
+		for _, ref := range t.References {
+			target := ref.Table
+			if ref.Schema != "" {
+				target = ref.Schema + "." + target
+			}
+			if ref.Deferred {
+				fmt.Printf("       %v -> %v.%v, set once every table is filled\n", ref.Column, target, ref.RefColumn)
+				continue
+			}
+			fmt.Printf("       %v -> %v.%v\n", ref.Column, target, ref.RefColumn)
+		}
+		for _, check := range t.Checks {
+			fmt.Printf("       CHECK (%v)\n", check)
+		}
+	}
+	for _, res := range seeder.Results {
+		fmt.Printf("  - %v skipped, %v\n", res.Table, res.Skipped)
+	}
+	fmt.Printf("-------------------------\n")
+	return nil
+}
+
+// fills the empty tables of the schema found in the database, there are no migrations involved
+func seedIntrospected(ctx context.Context, store *types.Store, opts *cliOptions) error {
+	start := time.Now()
+
+	fmt.Printf("Introspecting schema %v...\n", opts.schema)
+	seeder, err := types.IntrospectSeeder(store, opts.schema, opts.schemaSeeder...)
+	if err != nil {
+		return err
 	}
 
-	if migration_enable {
-		migrate(store)
+	fmt.Println("Seeding...")
+	if err := seeder.Seed(ctx); err != nil {
+		return err
 	}
 
+	seeder.PrintResults()
+	fmt.Printf("Finished in %v\n\n", time.Since(start))
+	return nil
+}
+
+// seeds the database and finalizes the migrations defered by the up migrations
+func seed(ctx context.Context, store *types.Store, opts *cliOptions) error {
 	fmt.Println("Seeding...")
-	seeder := types.NewSeeder(store)
-	seeder.Seed()
+	seeder, err := types.NewSeeder(store, opts.seeder...)
+	if err != nil {
+		return err
+	}
+	if err := seeder.Seed(ctx); err != nil {
+		return err
+	}
 
 	fmt.Println("Finishing up...")
-	finalize(store)
+	if err := finalize(store, opts.migrationPath); err != nil {
+		return err
+	}
 
-	finished := time.Since(start)
+	// everything is in, there's nothing left to resume
+	if opts.checkpoint != "" {
+		if err := os.Remove(opts.checkpoint); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
 
 	seeder.PrintResults()
-	fmt.Printf("Finished in %v\n\n", finished)
+	return nil
 }
 
-// runs migrations according to the configurations set above at the top of this file
-func migrate(s *types.Store) {
-	migrations, err := database.Migrations(migration_path)
+// runs the next n pending up migrations (all of them when n is 0), rolling the database back
+// to a clean state first if requested. the transatory phase of the applied migrations is left
+// for finalize to run once seeding is done.
+func migrate(s *types.Store, path string, n int, rollbackFirst bool) error {
+	migrator, err := database.NewMigrator(s.DB, path)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	// if rollback, run down migrations in reverse order to reset the database to a clean state
-	if migration_rollback {
+	if rollbackFirst {
 		fmt.Println("Rolling back migrations...")
-		for i := range migrations {
-			m := migrations[len(migrations)-i+1]
-			err := s.Execute(string(m.Down))
-			if err != nil {
-				log.Fatal(err)
-			}
+		if _, err := migrator.Down(0); err != nil {
+			return err
 		}
 	}
 
-	// run up migrations in sequence
+	// run pending up migrations in sequence
 	fmt.Println("Running migrations...")
-	for _, m := range migrations {
-		err := s.Execute(string(m.Up))
-		if err != nil {
-			log.Fatal(err)
+	ran, err := migrator.Up(n)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrations finished, %v applied.\n", len(ran))
+	return nil
+}
+
+// runs the down migrations of the last n applied migrations (all of them when n is 0) in reverse
+// order to reset the database to a clean state
+func rollback(s *types.Store, path string, n int) error {
+	migrator, err := database.NewMigrator(s.DB, path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Rolling back migrations...")
+	_, err = migrator.Down(n)
+	return err
+}
+
+// seeding an already finalized schema requires the transatory phase to be undone first, and the
+// previously seeded rows to be cleared since the seeder assigns ids from 1. this only happens
+// when asked for with -reseed.
+func prepareReseed(s *types.Store, opts *cliOptions) error {
+	migrator, err := database.NewMigrator(s.DB, opts.migrationPath)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	finalized := false
+	for _, ms := range statuses {
+		if ms.FinalizedAt != nil {
+			finalized = true
 		}
+	}
 
-		m.Finished = true
+	if !finalized {
+		return nil
+	}
+
+	if !opts.reseed {
+		return fmt.Errorf("the schema was already seeded and finalized, run seed with -reseed to clear the seeded tables and seed again")
+	}
 
-		if m.Transatory != nil && len(m.Transatory) > 0 {
-			defered_migrations[m.Index] = m.Transatory
-			m.Finished = false
+	fmt.Println("Removing transatory constraints...")
+	if _, err := migrator.Unfinalize(); err != nil {
+		return err
+	}
+
+	fmt.Println("Clearing seeded tables...")
+	tables := []string{}
+	for _, table := range types.SeedSchema().SeededTables() {
+		tables = append(tables, table.Name)
+	}
+	return s.Truncate(tables...)
+}
+
+// resuming picks up the seed, configuration and progress of the interrupted run from its
+// checkpoint. without one the row counts of the seeded tables tell where to continue, which only
+// works if the seed and flags of the interrupted run are given again, the seed is required.
+func prepareResume(opts *cliOptions) error {
+	if !opts.resume || opts.checkpoint == "" {
+		return nil
+	}
+
+	cp, err := types.LoadCheckpoint(opts.checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		if !opts.seeded {
+			return fmt.Errorf("no checkpoint at %v, -resume needs the -seed of the interrupted run without one", opts.checkpoint)
 		}
+		fmt.Printf("No checkpoint at %v, continuing after the rows already in the tables...\n", opts.checkpoint)
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("Migrations finished.")
+	fmt.Printf("Resuming seed %v from %v...\n", cp.Seed, opts.checkpoint)
+	opts.seeder = append(opts.seeder, types.SeederCfgResumeFrom(cp))
+	return nil
 }
 
-// finalizes the migrations by running migrations defered by previous migrations
+// runs the transatory phase of every applied migration not yet finalized, in order
 // these mostly consist of key constraints
-func finalize(s *types.Store) {
-	for _, m := range defered_migrations {
-		err := s.Execute(string(m))
-		if err != nil {
-			fmt.Printf("Migration: %v\n\n", string(m))
-			log.Fatal("Defered Migration Failure:", err.Error())
+func finalize(s *types.Store, path string) error {
+	migrator, err := database.NewMigrator(s.DB, path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := migrator.Finalize(); err != nil {
+		var migErr *database.MigrationError
+		if errors.As(err, &migErr) {
+			fmt.Printf("Statement: %v\n\n", migErr.SQL)
 		}
+		return fmt.Errorf("defered migration failure: %w", err)
 	}
+	return nil
+}
+
+// undoes the transatory phase of every finalized migration in reverse order
+func unfinalize(s *types.Store, path string) error {
+	migrator, err := database.NewMigrator(s.DB, path)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Unfinalize()
+	return err
 }
@@ -5,7 +5,6 @@ DROP TABLE IF EXISTS post_contents;
 DROP TABLE IF EXISTS identity_statuses;
 DROP TABLE IF EXISTS identity_styles;
 DROP TABLE IF EXISTS threads;
-DROP TABLE IF EXISTS thread_contents;
 DROP TABLE IF EXISTS thread_roles;
 DROP TABLE IF EXISTS thread_statuses;
 DROP TABLE IF EXISTS boards;
@@ -14,4 +13,4 @@ DROP TABLE IF EXISTS article_contents;
 DROP TABLE IF EXISTS article_statuses;
 DROP TABLE IF EXISTS accounts;
 DROP TABLE IF EXISTS account_statuses;
-DROP TABLE IF EXISTS account_roles;
\ No newline at end of file
+DROP TABLE IF EXISTS account_roles;
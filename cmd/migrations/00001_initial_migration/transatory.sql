@@ -1,88 +1,91 @@
--- account id primary key update
+-- accounts keys
 ALTER TABLE accounts
-  ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
-	ADD PRIMARY KEY (id);
-
-SELECT setval(pg_get_serial_sequence('accounts', 'id'),
-  (SELECT MAX(id) FROM accounts));
-
-
--- board id primary key update
-ALTER TABLE boards
 	ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
-	ADD PRIMARY KEY (id);
+	ADD CONSTRAINT accounts_pkey PRIMARY KEY (id);
 
-SELECT setval(pg_get_serial_sequence('boards', 'id'),
-	(SELECT MAX(id) FROM boards));
+SELECT setval(pg_get_serial_sequence('accounts', 'id'),
+	(SELECT MAX(id) FROM accounts));
 
 
--- article contents id primary key update
+-- article_contents keys
 ALTER TABLE article_contents
 	ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
-	ADD PRIMARY KEY (id);
+	ADD CONSTRAINT article_contents_pkey PRIMARY KEY (id);
 
 SELECT setval(pg_get_serial_sequence('article_contents', 'id'),
 	(SELECT MAX(id) FROM article_contents));
 
 
--- article id primary key update
+-- articles keys
 ALTER TABLE articles
 	ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
-	ADD PRIMARY KEY (id),
-	ADD FOREIGN KEY (author_id) REFERENCES accounts (id),
-	ADD FOREIGN KEY (content_id) REFERENCES article_contents (id);
+	ADD CONSTRAINT articles_pkey PRIMARY KEY (id),
+	ADD CONSTRAINT articles_author_id_fkey FOREIGN KEY (author_id) REFERENCES accounts (id),
+	ADD CONSTRAINT articles_content_id_fkey FOREIGN KEY (content_id) REFERENCES article_contents (id);
 
 SELECT setval(pg_get_serial_sequence('articles', 'id'),
 	(SELECT MAX(id) FROM articles));
 
 
--- thread id primary key update
+-- boards keys
+ALTER TABLE boards
+	ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
+	ADD CONSTRAINT boards_pkey PRIMARY KEY (id);
+
+SELECT setval(pg_get_serial_sequence('boards', 'id'),
+	(SELECT MAX(id) FROM boards));
+
+
+-- threads keys
 ALTER TABLE threads
 	ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
-	ADD PRIMARY KEY (id),
-	ADD FOREIGN KEY (board_id) REFERENCES boards (id);
+	ADD CONSTRAINT threads_pkey PRIMARY KEY (id),
+	ADD CONSTRAINT threads_board_id_fkey FOREIGN KEY (board_id) REFERENCES boards (id);
 
 SELECT setval(pg_get_serial_sequence('threads', 'id'),
 	(SELECT MAX(id) FROM threads));
 
 
--- post contents id primary key update
+-- post_contents keys
 ALTER TABLE post_contents
 	ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
-	ADD PRIMARY KEY (id);
+	ADD CONSTRAINT post_contents_pkey PRIMARY KEY (id);
 
 SELECT setval(pg_get_serial_sequence('post_contents', 'id'),
 	(SELECT MAX(id) FROM post_contents));
 
 
--- post id primary key update
+-- posts keys
 ALTER TABLE posts
 	ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
-	ADD PRIMARY KEY (id),
-	ADD FOREIGN KEY (board_id) REFERENCES boards (id),
-	ADD FOREIGN KEY (thread_id) REFERENCES threads (id),
-	ADD FOREIGN KEY (content_id) REFERENCES post_contents (id),
-	ADD FOREIGN KEY (account_id) REFERENCES accounts (id);
+	ADD CONSTRAINT posts_pkey PRIMARY KEY (id),
+	ADD CONSTRAINT posts_board_id_fkey FOREIGN KEY (board_id) REFERENCES boards (id),
+	ADD CONSTRAINT posts_thread_id_fkey FOREIGN KEY (thread_id) REFERENCES threads (id),
+	ADD CONSTRAINT posts_content_id_fkey FOREIGN KEY (content_id) REFERENCES post_contents (id),
+	ADD CONSTRAINT posts_account_id_fkey FOREIGN KEY (account_id) REFERENCES accounts (id);
 
 SELECT setval(pg_get_serial_sequence('posts', 'id'),
 	(SELECT MAX(id) FROM posts));
 
 
--- identity id primary key update
+-- identities keys
 ALTER TABLE identities
 	ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
-  ADD PRIMARY KEY (id),
-	ADD FOREIGN KEY (thread_id) REFERENCES threads (id),
-	ADD FOREIGN KEY (account_id) REFERENCES accounts (id);
+	ADD CONSTRAINT identities_pkey PRIMARY KEY (id),
+	ADD CONSTRAINT identities_thread_id_fkey FOREIGN KEY (thread_id) REFERENCES threads (id),
+	ADD CONSTRAINT identities_account_id_fkey FOREIGN KEY (account_id) REFERENCES accounts (id);
 
 SELECT setval(pg_get_serial_sequence('identities', 'id'),
 	(SELECT MAX(id) FROM identities));
 
 
--- identity posts id primary key update
+-- identity_posts keys
 ALTER TABLE identity_posts
 	ALTER id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1),
-	ADD PRIMARY KEY (id),
-	ADD FOREIGN KEY (identity_id) REFERENCES identities (id),
-	ADD FOREIGN KEY (board_id) REFERENCES boards (id),
-	ADD FOREIGN KEY (post_id) REFERENCES posts (id);
\ No newline at end of file
+	ADD CONSTRAINT identity_posts_pkey PRIMARY KEY (id),
+	ADD CONSTRAINT identity_posts_identity_id_fkey FOREIGN KEY (identity_id) REFERENCES identities (id),
+	ADD CONSTRAINT identity_posts_board_id_fkey FOREIGN KEY (board_id) REFERENCES boards (id),
+	ADD CONSTRAINT identity_posts_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts (id);
+
+SELECT setval(pg_get_serial_sequence('identity_posts', 'id'),
+	(SELECT MAX(id) FROM identity_posts));
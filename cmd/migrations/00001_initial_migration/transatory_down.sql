@@ -0,0 +1,64 @@
+-- identity_posts keys
+ALTER TABLE identity_posts
+	DROP CONSTRAINT IF EXISTS identity_posts_post_id_fkey,
+	DROP CONSTRAINT IF EXISTS identity_posts_board_id_fkey,
+	DROP CONSTRAINT IF EXISTS identity_posts_identity_id_fkey,
+	DROP CONSTRAINT IF EXISTS identity_posts_pkey,
+	ALTER id DROP IDENTITY IF EXISTS;
+
+
+-- identities keys
+ALTER TABLE identities
+	DROP CONSTRAINT IF EXISTS identities_account_id_fkey,
+	DROP CONSTRAINT IF EXISTS identities_thread_id_fkey,
+	DROP CONSTRAINT IF EXISTS identities_pkey,
+	ALTER id DROP IDENTITY IF EXISTS;
+
+
+-- posts keys
+ALTER TABLE posts
+	DROP CONSTRAINT IF EXISTS posts_account_id_fkey,
+	DROP CONSTRAINT IF EXISTS posts_content_id_fkey,
+	DROP CONSTRAINT IF EXISTS posts_thread_id_fkey,
+	DROP CONSTRAINT IF EXISTS posts_board_id_fkey,
+	DROP CONSTRAINT IF EXISTS posts_pkey,
+	ALTER id DROP IDENTITY IF EXISTS;
+
+
+-- post_contents keys
+ALTER TABLE post_contents
+	DROP CONSTRAINT IF EXISTS post_contents_pkey,
+	ALTER id DROP IDENTITY IF EXISTS;
+
+
+-- threads keys
+ALTER TABLE threads
+	DROP CONSTRAINT IF EXISTS threads_board_id_fkey,
+	DROP CONSTRAINT IF EXISTS threads_pkey,
+	ALTER id DROP IDENTITY IF EXISTS;
+
+
+-- boards keys
+ALTER TABLE boards
+	DROP CONSTRAINT IF EXISTS boards_pkey,
+	ALTER id DROP IDENTITY IF EXISTS;
+
+
+-- articles keys
+ALTER TABLE articles
+	DROP CONSTRAINT IF EXISTS articles_content_id_fkey,
+	DROP CONSTRAINT IF EXISTS articles_author_id_fkey,
+	DROP CONSTRAINT IF EXISTS articles_pkey,
+	ALTER id DROP IDENTITY IF EXISTS;
+
+
+-- article_contents keys
+ALTER TABLE article_contents
+	DROP CONSTRAINT IF EXISTS article_contents_pkey,
+	ALTER id DROP IDENTITY IF EXISTS;
+
+
+-- accounts keys
+ALTER TABLE accounts
+	DROP CONSTRAINT IF EXISTS accounts_pkey,
+	ALTER id DROP IDENTITY IF EXISTS;
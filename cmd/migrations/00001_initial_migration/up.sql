@@ -1,17 +1,16 @@
 -- account_roles
-
 CREATE TABLE IF NOT EXISTS account_roles (
-  id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
-  role VARCHAR(31) NOT NULL UNIQUE
+	id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	role VARCHAR(31) NOT NULL UNIQUE
 );
 
 INSERT INTO account_roles
-  (role)
+	(role)
 VALUES
-  ('user'),
-  ('moderator'),
-  ('admin'),
-  ('super');
+	('user'),
+	('moderator'),
+	('admin'),
+	('super');
 
 
 -- account_statuses
@@ -20,13 +19,13 @@ CREATE TABLE IF NOT EXISTS account_statuses (
 	status VARCHAR(31) NOT NULL UNIQUE
 );
 
-INSERT INTO account_statuses 
-  (status) 
+INSERT INTO account_statuses
+	(status)
 VALUES
-  ('active'),
-  ('inactive'),
-  ('suspended'),
-  ('banned');
+	('active'),
+	('inactive'),
+	('suspended'),
+	('banned');
 
 
 -- accounts
@@ -37,14 +36,13 @@ CREATE TABLE IF NOT EXISTS accounts (
 	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
 	updated_at TIMESTAMP NOT NULL DEFAULT NOW() CHECK (updated_at >= created_at),
 	deleted_at TIMESTAMP,
-
 	role_id INT NOT NULL DEFAULT 1,
 	status_id INT NOT NULL DEFAULT 1,
-
 	FOREIGN KEY (role_id) REFERENCES account_roles (id),
 	FOREIGN KEY (status_id) REFERENCES account_statuses (id)
 );
 
+
 -- article_statuses
 CREATE TABLE IF NOT EXISTS article_statuses (
 	id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
@@ -63,8 +61,8 @@ VALUES
 
 -- article_contents
 CREATE TABLE IF NOT EXISTS article_contents (
-  id INT UNIQUE,
-  content TEXT
+	id INT UNIQUE,
+	content TEXT
 );
 
 
@@ -73,7 +71,7 @@ CREATE TABLE IF NOT EXISTS articles (
 	id INT UNIQUE,
 	author_id INT,
 	status_id INT DEFAULT 1,
-  content_id INT UNIQUE,
+	content_id INT UNIQUE,
 	title VARCHAR(127) NOT NULL,
 	slug VARCHAR(63) NOT NULL UNIQUE,
 	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
@@ -81,13 +79,13 @@ CREATE TABLE IF NOT EXISTS articles (
 	deleted_at TIMESTAMP,
 	FOREIGN KEY (status_id) REFERENCES article_statuses (id)
 );
-	
+
 
 -- boards
 CREATE TABLE IF NOT EXISTS boards (
 	id INT UNIQUE,
-	title VARCHAR(63) UNIQUE NOT NULL,
-	short VARCHAR(7) UNIQUE NOT NULL,
+	title VARCHAR(63) NOT NULL UNIQUE,
+	short VARCHAR(7) NOT NULL UNIQUE,
 	description VARCHAR(255) NOT NULL,
 	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
 	updated_at TIMESTAMP NOT NULL DEFAULT NOW() CHECK (updated_at >= created_at),
@@ -105,11 +103,11 @@ CREATE TABLE IF NOT EXISTS thread_statuses (
 INSERT INTO thread_statuses
 	(status)
 VALUES
-	('open'),                     -- 1
-  ('locked'),                   -- 2
-	('closed'),                   -- 3
-	('archived'),                 -- 4
-	('removed');                  -- 5
+	('open'),
+	('locked'),
+	('closed'),
+	('archived'),
+	('removed');
 
 
 -- thread_roles
@@ -121,32 +119,21 @@ CREATE TABLE IF NOT EXISTS thread_roles (
 INSERT INTO thread_roles
 	(role)
 VALUES
-	('user'),                     -- 1
-	('moderator'),                -- 2
-	('creator');                  -- 3
-
-
--- -- thread_contents
--- CREATE TABLE IF NOT EXISTS thread_contents (
---   id INT UNIQUE,
---   content TEXT NOT NULL
--- );
+	('user'),
+	('moderator'),
+	('creator');
 
 
 -- threads
 CREATE TABLE IF NOT EXISTS threads (
 	id INT UNIQUE,
-
 	board_id INT NOT NULL,
 	status_id INT NOT NULL DEFAULT 1,
-
 	title VARCHAR(127) NOT NULL,
 	slug VARCHAR(127) NOT NULL UNIQUE,
-	
 	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
 	updated_at TIMESTAMP NOT NULL DEFAULT NOW() CHECK (updated_at >= created_at),
 	deleted_at TIMESTAMP,
-	
 	FOREIGN KEY (status_id) REFERENCES thread_statuses (id)
 );
 
@@ -154,40 +141,40 @@ CREATE TABLE IF NOT EXISTS threads (
 -- identity_styles
 CREATE TABLE IF NOT EXISTS identity_styles (
 	id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
-	style VARCHAR(63) UNIQUE NOT NULL
+	style VARCHAR(63) NOT NULL UNIQUE
 );
 
-INSERT INTO identity_styles 
+INSERT INTO identity_styles
 	(style)
 VALUES
-	('ids-filled-primary'),       -- 1
-	('ids-filled-secondary'),     -- 2
-	('ids-filled-tertiary'),      -- 3
-	('ids-filled-success'),       -- 4
-	('ids-filled-warning'),       -- 5
-	('ids-filled-error'),         -- 6
-	('ids-filled-surface'),       -- 7
-	('ids-ghost-primary'),        -- 8
-	('ids-ghost-secondary'),      -- 9
-	('ids-ghost-tertiary'),       -- 10
-	('ids-ghost-success'),        -- 11
-	('ids-ghost-warning'),        -- 12
-	('ids-ghost-error'),          -- 13
-	('ids-ghost-surface'),        -- 14
-	('ids-soft-primary'),         -- 15
-	('ids-soft-secondary'),       -- 16
-	('ids-soft-tertiary'),        -- 17
-	('ids-soft-success'),         -- 18
-	('ids-soft-warning'),         -- 19
-	('ids-soft-error'),           -- 20
-	('ids-soft-surface'),         -- 21
-	('ids-glass-primary'),        -- 22
-	('ids-glass-secondary'),      -- 23
-	('ids-glass-tertiary'),       -- 24
-	('ids-glass-success'),        -- 25
-	('ids-glass-warning'),        -- 26
-	('ids-glass-error'),          -- 27
-	('ids-glass-surface');        -- 28
+	('ids-filled-primary'),
+	('ids-filled-secondary'),
+	('ids-filled-tertiary'),
+	('ids-filled-success'),
+	('ids-filled-warning'),
+	('ids-filled-error'),
+	('ids-filled-surface'),
+	('ids-ghost-primary'),
+	('ids-ghost-secondary'),
+	('ids-ghost-tertiary'),
+	('ids-ghost-success'),
+	('ids-ghost-warning'),
+	('ids-ghost-error'),
+	('ids-ghost-surface'),
+	('ids-soft-primary'),
+	('ids-soft-secondary'),
+	('ids-soft-tertiary'),
+	('ids-soft-success'),
+	('ids-soft-warning'),
+	('ids-soft-error'),
+	('ids-soft-surface'),
+	('ids-glass-primary'),
+	('ids-glass-secondary'),
+	('ids-glass-tertiary'),
+	('ids-glass-success'),
+	('ids-glass-warning'),
+	('ids-glass-error'),
+	('ids-glass-surface');
 
 
 -- identity_statuses
@@ -199,60 +186,51 @@ CREATE TABLE IF NOT EXISTS identity_statuses (
 INSERT INTO identity_statuses
 	(status)
 VALUES
-	('active'),                   -- 1
-	('inactive'),                 -- 2
-	('suspended'),                -- 3
-	('banned');                   -- 4
+	('active'),
+	('inactive'),
+	('suspended'),
+	('banned');
 
 
 -- post_contents
 CREATE TABLE IF NOT EXISTS post_contents (
-  id INT UNIQUE,
-  content TEXT NOT NULL
+	id INT UNIQUE,
+	content TEXT NOT NULL
 );
 
 
 -- posts
 CREATE TABLE IF NOT EXISTS posts (
 	id INT UNIQUE,
-
 	board_id INT NOT NULL,
 	thread_id INT NOT NULL,
 	account_id INT NOT NULL,
-  content_id INT NOT NULL,
-	
+	content_id INT NOT NULL,
 	post_number INT NOT NULL,
-	
 	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
 	updated_at TIMESTAMP NOT NULL DEFAULT NOW() CHECK (updated_at >= created_at),
 	deleted_at TIMESTAMP,
-
 	UNIQUE (board_id, post_number)
 );
 
+
 -- identities
 CREATE TABLE IF NOT EXISTS identities (
 	id INT UNIQUE,
-
 	board_id INT NOT NULL,
 	thread_id INT NOT NULL,
 	account_id INT NOT NULL,
-
 	name VARCHAR(31) NOT NULL,
-	
 	style_id INT NOT NULL,
 	status_id INT NOT NULL DEFAULT 1,
 	role_id INT NOT NULL DEFAULT 1,
-
 	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
 	updated_at TIMESTAMP NOT NULL DEFAULT NOW() CHECK (updated_at >= created_at),
 	deleted_at TIMESTAMP,
-	
+	UNIQUE (board_id, thread_id, account_id),
 	FOREIGN KEY (role_id) REFERENCES thread_roles (id),
 	FOREIGN KEY (style_id) REFERENCES identity_styles (id),
-	FOREIGN KEY (status_id) REFERENCES identity_statuses (id),
-
-	UNIQUE (board_id, thread_id, account_id)
+	FOREIGN KEY (status_id) REFERENCES identity_statuses (id)
 );
 
 
@@ -262,4 +240,4 @@ CREATE TABLE IF NOT EXISTS identity_posts (
 	identity_id INT NOT NULL,
 	board_id INT NOT NULL,
 	post_id INT NOT NULL
-);
\ No newline at end of file
+);
@@ -3,5 +3,3 @@ module github.com/dd-web/pgsvk-seeder
 go 1.20
 
 require github.com/lib/pq v1.10.9
-
-require github.com/matoous/go-nanoid/v2 v2.0.0 // indirect
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+/* INTROSPECTION */
+/*****************/
+
+var (
+	introspect_tables_query string = `SELECT c.relname
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+ORDER BY c.relname`
+
+	introspect_columns_query string = `SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+	COALESCE(pg_get_expr(d.adbin, d.adrelid), ''),
+	a.attidentity = 'a' OR a.attgenerated <> '',
+	CASE WHEN t.typname IN ('varchar', 'bpchar') AND a.atttypmod > 4 THEN a.atttypmod - 4 ELSE 0 END,
+	ARRAY(SELECT e.enumlabel FROM pg_enum e WHERE e.enumtypid = a.atttypid ORDER BY e.enumsortorder)::text[]
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_type t ON t.oid = a.atttypid
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY c.relname, a.attnum`
+
+	introspect_constraints_query string = `SELECT rel.relname, con.contype,
+	ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum ORDER BY k.ord)::text[],
+	COALESCE(frel.relname, ''), COALESCE(fns.nspname, ''),
+	ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum ORDER BY k.ord)::text[],
+	pg_get_constraintdef(con.oid)
+FROM pg_constraint con
+JOIN pg_class rel ON rel.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = rel.relnamespace
+LEFT JOIN pg_class frel ON frel.oid = con.confrelid
+LEFT JOIN pg_namespace fns ON fns.oid = frel.relnamespace
+WHERE n.nspname = $1 AND con.contype IN ('p', 'u', 'f', 'c')
+ORDER BY rel.relname, con.conname`
+
+	// unique indexes on plain columns that no constraint stands for, CREATE UNIQUE INDEX. a partial
+	// index is read as unique over the whole table, an expression index can't be described.
+	introspect_unique_indexes_query string = `SELECT rel.relname,
+	ARRAY(SELECT a.attname FROM unnest(i.indkey::int2[]) WITH ORDINALITY k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum ORDER BY k.ord)::text[]
+FROM pg_index i
+JOIN pg_class rel ON rel.oid = i.indrelid
+JOIN pg_class idx ON idx.oid = i.indexrelid
+JOIN pg_namespace n ON n.oid = rel.relnamespace
+WHERE n.nspname = $1 AND i.indisunique AND NOT i.indisprimary AND 0 <> ALL (i.indkey::int2[])
+	AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conrelid = i.indrelid AND con.conindid = i.indexrelid)
+ORDER BY rel.relname, idx.relname`
+)
+
+// Introspect reads the tables of the given postgres schema (usually "public") from the catalog and
+// describes them as a Schema: columns with their types, defaults and NOT NULL, primary keys,
+// UNIQUE, CHECK and FOREIGN KEY constraints and unique indexes. tables listed in exclude are left out. a foreign key
+// into another schema keeps the schema of its table, the table isn't part of the result.
+//
+// only single column primary and foreign keys can be described, a table with a composite one is left
+// out along with the tables referencing it, they're listed in Skipped with the reason. the result has
+// no Copy columns or lookup Rows, what to fill is up to the caller.
+func Introspect(db *sql.DB, schema string, exclude ...string) (*Schema, error) {
+	excluded := map[string]bool{}
+	for _, name := range exclude {
+		excluded[name] = true
+	}
+
+	s := &Schema{Skipped: map[string]string{}}
+
+	rows, err := db.Query(introspect_tables_query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("introspect tables: %v", err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !excluded[name] {
+			s.Tables = append(s.Tables, &Table{Name: name})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := introspectColumns(db, s, schema); err != nil {
+		return nil, err
+	}
+
+	if err := introspectConstraints(db, s, schema); err != nil {
+		return nil, err
+	}
+
+	if err := introspectUniqueIndexes(db, s, schema); err != nil {
+		return nil, err
+	}
+
+	skipUnsupported(s)
+	return s, nil
+}
+
+func introspectColumns(db *sql.DB, s *Schema, schema string) error {
+	rows, err := db.Query(introspect_columns_query, schema)
+	if err != nil {
+		return fmt.Errorf("introspect columns: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table string
+		var enum pq.StringArray
+		c := &Column{}
+		if err := rows.Scan(&table, &c.Name, &c.Type, &c.NotNull, &c.Default, &c.Generated, &c.Length, &enum); err != nil {
+			return err
+		}
+		c.Enum = enum
+
+		if t := s.Table(table); t != nil {
+			t.Columns = append(t.Columns, c)
+		}
+	}
+
+	return rows.Err()
+}
+
+func introspectConstraints(db *sql.DB, s *Schema, schema string) error {
+	rows, err := db.Query(introspect_constraints_query, schema)
+	if err != nil {
+		return fmt.Errorf("introspect constraints: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, kind, refTable, refSchema, def string
+		var cols, refCols pq.StringArray
+		if err := rows.Scan(&table, &kind, &cols, &refTable, &refSchema, &refCols, &def); err != nil {
+			return err
+		}
+
+		t := s.Table(table)
+		if t == nil {
+			continue
+		}
+
+		switch kind {
+		case "p":
+			if len(cols) != 1 {
+				s.Skipped[table] = fmt.Sprintf("composite primary key (%s) is not supported", strings.Join(cols, ", "))
+				continue
+			}
+			t.PrimaryKey = cols[0]
+		case "u":
+			if len(cols) == 1 {
+				t.Column(cols[0]).Unique = true
+			} else {
+				t.Unique = append(t.Unique, cols)
+			}
+		case "f":
+			if len(cols) != 1 {
+				s.Skipped[table] = fmt.Sprintf("composite foreign key (%s) is not supported", strings.Join(cols, ", "))
+				continue
+			}
+			ref := &Reference{Column: cols[0], Table: refTable, RefColumn: refCols[0]}
+			if refSchema != schema {
+				ref.Schema = refSchema
+			}
+			t.References = append(t.References, ref)
+		case "c":
+			t.Checks = append(t.Checks, checkExpression(def))
+		}
+	}
+
+	return rows.Err()
+}
+
+// unique indexes keep their columns unique just like UNIQUE constraints, they're described the same
+func introspectUniqueIndexes(db *sql.DB, s *Schema, schema string) error {
+	rows, err := db.Query(introspect_unique_indexes_query, schema)
+	if err != nil {
+		return fmt.Errorf("introspect unique indexes: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table string
+		var cols pq.StringArray
+		if err := rows.Scan(&table, &cols); err != nil {
+			return err
+		}
+
+		t := s.Table(table)
+		if t == nil {
+			continue
+		}
+
+		if len(cols) == 1 {
+			t.Column(cols[0]).Unique = true
+		} else {
+			t.Unique = append(t.Unique, cols)
+		}
+	}
+
+	return rows.Err()
+}
+
+// drops the skipped tables from the schema, then the tables referencing a dropped one until none is
+// left, their keys can't be picked from a table that isn't described
+func skipUnsupported(s *Schema) {
+	for {
+		dropped := false
+		for _, t := range s.Tables {
+			if _, ok := s.Skipped[t.Name]; ok {
+				continue
+			}
+			for _, ref := range t.References {
+				if _, ok := s.Skipped[ref.Table]; ok && ref.Schema == "" && ref.Table != t.Name {
+					s.Skipped[t.Name] = fmt.Sprintf("references %s, which is skipped", ref.Table)
+					dropped = true
+					break
+				}
+			}
+		}
+		if !dropped {
+			break
+		}
+	}
+
+	tables := s.Tables[:0]
+	for _, t := range s.Tables {
+		if _, ok := s.Skipped[t.Name]; !ok {
+			tables = append(tables, t)
+		}
+	}
+	s.Tables = tables
+}
+
+// turns a constraint definition like CHECK ((a >= b)) into its expression, (a >= b)
+func checkExpression(def string) string {
+	expr := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(def), "NOT VALID"))
+	expr = strings.TrimPrefix(expr, "CHECK ")
+	if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		expr = expr[1 : len(expr)-1]
+	}
+	return expr
+}
@@ -1,18 +1,40 @@
 package database
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 )
 
+// a migration consists of two phases. the up phase (up.sql / down.sql) creates the schema before
+// seeding, the transatory phase (transatory.sql / transatory_down.sql) runs after seeding and adds
+// what would slow the seeding down, like keys, identity columns and their sequences.
 type Migration struct {
-	Index      int
-	Up         []byte
-	Down       []byte
-	Transatory []byte
-	Finished   bool
+	Index          int
+	Name           string
+	Up             []byte
+	Down           []byte
+	Transatory     []byte
+	TransatoryDown []byte
+	Finished       bool
+}
+
+// whether the migration has a transatory phase
+func (m *Migration) HasTransatory() bool {
+	return len(m.Transatory) > 0
+}
+
+// returns the hex encoded sha256 of the scripts that shape the schema, up.sql and transatory.sql.
+// down.sql is left out on purpose, editing it doesn't change what an applied migration did.
+func (m *Migration) Checksum() string {
+	h := sha256.New()
+	h.Write(m.Up)
+	h.Write(m.Transatory)
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // reads bytes from a file and returns them
@@ -24,14 +46,14 @@ func readbytes(path string) ([]byte, error) {
 	return bs, nil
 }
 
-// creates the migration from the given path, populate the up and down fields and return it
-func createMigration(path string, index int) (*Migration, error) {
+// creates the migration from the given path, populate the up, down and transatory fields and return it
+func createMigration(path string, name string, index int) (*Migration, error) {
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
 
-	var migration Migration = Migration{Finished: false, Index: index}
+	var migration Migration = Migration{Finished: false, Index: index, Name: name}
 
 	for _, item := range entries {
 		if item.IsDir() {
@@ -50,14 +72,17 @@ func createMigration(path string, index int) (*Migration, error) {
 			migration.Down = bs
 		case "transatory.sql":
 			migration.Transatory = bs
+		case "transatory_down.sql":
+			migration.TransatoryDown = bs
 		default:
-			return nil, fmt.Errorf("found file in migration directory that is not up.sql or down.sql: %s", item.Name())
+			return nil, fmt.Errorf("found file in migration directory that is not up.sql, down.sql, transatory.sql or transatory_down.sql: %s", item.Name())
 		}
 	}
 	return &migration, nil
 }
 
 // parse the directory for migration paths and create a map of migrations
+// two directories sharing the same index prefix are reported as an error
 func parseMigrationDir(path string) (map[int]*Migration, error) {
 	entries, err := os.ReadDir(path)
 	if err != nil {
@@ -68,7 +93,7 @@ func parseMigrationDir(path string) (map[int]*Migration, error) {
 
 	for _, item := range entries {
 		if item.IsDir() {
-			match, err := regexp.Match("\\d{5}_.*", []byte(item.Name()))
+			match, err := regexp.Match("^\\d{5}_.*", []byte(item.Name()))
 			if err != nil {
 				return nil, err
 			}
@@ -79,7 +104,11 @@ func parseMigrationDir(path string) (map[int]*Migration, error) {
 					return nil, err
 				}
 
-				migration, err := createMigration(path+"/"+item.Name(), iter)
+				if exist, ok := migrations[iter]; ok {
+					return nil, fmt.Errorf("duplicate migration index %05d: %s and %s", iter, exist.Name, item.Name())
+				}
+
+				migration, err := createMigration(path+"/"+item.Name(), item.Name(), iter)
 				if err != nil {
 					return nil, err
 				}
@@ -92,10 +121,34 @@ func parseMigrationDir(path string) (map[int]*Migration, error) {
 	return migrations, nil
 }
 
-// parse migrations directory and return a map of migrations that can be executed
+// sorts the migrations by index and makes sure they form an unbroken sequence starting at 00001
+func plan(migrations map[int]*Migration) ([]*Migration, error) {
+	sorted := make([]*Migration, 0, len(migrations))
+	for _, m := range migrations {
+		sorted = append(sorted, m)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	for i, m := range sorted {
+		if m.Index != i+1 {
+			return nil, fmt.Errorf("gap in migrations: expected %05d but found %s", i+1, m.Name)
+		}
+	}
+
+	return sorted, nil
+}
+
+// parse migrations directory and return the migrations that can be executed
 // migrations are sorted by their index in ascending order, which is the order they should be executed in
+// and validated to have unique indexes without gaps, starting at 00001
 // migrations include both the up and down sql scripts in bytes (should be converted to string before execution)
-// as well as a defered file called transatory.sql which is ran after seeding is complete
-func Migrations(path string) (map[int]*Migration, error) {
-	return parseMigrationDir(path)
+// as well as a defered file called transatory.sql which is ran after seeding is complete, and its
+// counterpart transatory_down.sql which undoes it so the seed can be ran again
+func Migrations(path string) ([]*Migration, error) {
+	migrations, err := parseMigrationDir(path)
+	if err != nil {
+		return nil, err
+	}
+	return plan(migrations)
 }
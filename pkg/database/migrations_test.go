@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	migration := func(index int) *Migration {
+		return &Migration{Index: index, Name: fmt.Sprintf("%05d_step", index)}
+	}
+
+	tests := []struct {
+		name    string
+		indexes []int
+		err     string
+	}{
+		{name: "none", indexes: nil},
+		{name: "in order", indexes: []int{1, 2, 3}},
+		{name: "sorted by index", indexes: []int{3, 1, 2}},
+		{name: "gap", indexes: []int{1, 2, 4}, err: "gap in migrations: expected 00003 but found 00004_step"},
+		{name: "not starting at 00001", indexes: []int{2, 3}, err: "gap in migrations: expected 00001 but found 00002_step"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations := map[int]*Migration{}
+			for _, i := range tt.indexes {
+				migrations[i] = migration(i)
+			}
+
+			sorted, err := plan(migrations)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(sorted) != len(tt.indexes) {
+				t.Fatalf("planned %d migrations, expected %d", len(sorted), len(tt.indexes))
+			}
+			for i, m := range sorted {
+				if m.Index != i+1 {
+					t.Errorf("migration %d of the plan is %05d", i+1, m.Index)
+				}
+			}
+		})
+	}
+}
+
+// creates a migration directory for every name, each with an empty up.sql
+func migrationDirs(t *testing.T, names ...string) string {
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, "up.sql"), []byte("SELECT 1;\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMigrations(t *testing.T) {
+	dir := migrationDirs(t, "00002_second", "00001_first", "notes")
+
+	migrations, err := Migrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "00001_first" || migrations[1].Name != "00002_second" {
+		t.Fatalf("expected 00001_first and 00002_second in order, got %v", migrations)
+	}
+	if string(migrations[0].Up) != "SELECT 1;\n" {
+		t.Errorf("up.sql read as %q", migrations[0].Up)
+	}
+}
+
+func TestMigrationsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		dirs []string
+		file string
+		err  string
+	}{
+		{name: "duplicate index", dirs: []string{"00001_first", "00001_again"}, err: "duplicate migration index 00001"},
+		{name: "gap", dirs: []string{"00001_first", "00003_third"}, err: "gap in migrations: expected 00002 but found 00003_third"},
+		{name: "unknown file", dirs: []string{"00001_first"}, file: "00001_first/seed.sql", err: "not up.sql, down.sql, transatory.sql or transatory_down.sql: seed.sql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := migrationDirs(t, tt.dirs...)
+			if tt.file != "" {
+				if err := os.WriteFile(filepath.Join(dir, tt.file), nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := Migrations(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,534 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+var (
+	migration_table string = "schema_migrations"
+
+	migration_table_ddl string = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum CHAR(64) NOT NULL,
+	applied_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	finalized_at TIMESTAMP
+);
+ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS finalized_at TIMESTAMP;`
+
+	// key of the session level advisory lock held while migrating, keeps two migrators from
+	// running against the same database at once
+	migration_lock_key int64 = 0x5eed_0001
+)
+
+// a row of the schema_migrations table
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+
+	// when the transatory phase was ran, nil while it's pending
+	FinalizedAt *time.Time
+}
+
+// the state of a single migration as reported by Migrator.Status
+type MigrationStatus struct {
+	Index     int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+
+	// whether the migration has a transatory phase and when it was ran
+	Transatory  bool
+	FinalizedAt *time.Time
+
+	// the migration file was changed after it was applied
+	Modified bool
+
+	// the migration was applied but is no longer on disk
+	Missing bool
+}
+
+func (ms MigrationStatus) String() string {
+	state := "pending"
+	switch {
+	case ms.Missing:
+		state = "applied, missing from disk"
+	case ms.Modified:
+		state = "applied, modified since"
+	case ms.Applied && ms.FinalizedAt != nil:
+		state = "applied " + ms.AppliedAt.Format(time.RFC3339) + ", finalized " + ms.FinalizedAt.Format(time.RFC3339)
+	case ms.Applied && ms.Transatory:
+		state = "applied " + ms.AppliedAt.Format(time.RFC3339) + ", transatory pending"
+	case ms.Applied:
+		state = "applied " + ms.AppliedAt.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%05d %-32s %s", ms.Index, ms.Name, state)
+}
+
+// reports the statement of a migration script that failed and where it's located
+type MigrationError struct {
+	Index int
+	Name  string
+
+	// the script the statement belongs to, up.sql, down.sql or transatory.sql
+	File string
+
+	// 1 based position of the failing statement in the script and the line it failed on
+	Statement int
+	Line      int
+	SQL       string
+
+	Err error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration %05d (%s) failed in %s at statement %d, line %d: %v", e.Index, e.Name, e.File, e.Statement, e.Line, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// anything queries can run on, a database, connection or transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Migrator applies migrations from a directory to a database, recording every applied migration
+// in the schema_migrations table so only pending migrations are ran. migrations are always applied
+// in ascending and rolled back in descending order, each inside its own transaction.
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+}
+
+func NewMigrator(db *sql.DB, path string) (*Migrator, error) {
+	migrations, err := Migrations(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(migration_table_ddl)
+	if err != nil {
+		return fmt.Errorf("could not create %s table: %v", migration_table, err)
+	}
+	return nil
+}
+
+// returns the applied migrations keyed by their version
+func (m *Migrator) applied(ctx context.Context, q querier) (map[int]*AppliedMigration, error) {
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("SELECT version, name, checksum, applied_at, finalized_at FROM %s", migration_table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]*AppliedMigration{}
+	for rows.Next() {
+		am := &AppliedMigration{}
+		var finalized sql.NullTime
+		if err := rows.Scan(&am.Version, &am.Name, &am.Checksum, &am.AppliedAt, &finalized); err != nil {
+			return nil, err
+		}
+		if finalized.Valid {
+			am.FinalizedAt = &finalized.Time
+		}
+		applied[am.Version] = am
+	}
+
+	return applied, rows.Err()
+}
+
+// runs fn on a dedicated connection holding the migration advisory lock, the lock is released
+// when fn returns
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migration_lock_key); err != nil {
+		return fmt.Errorf("could not acquire migration lock: %v", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migration_lock_key)
+
+	return fn(conn)
+}
+
+// runs every statement of the given script inside a single transaction followed by record, which
+// updates the schema_migrations table in the same transaction. anything failing rolls the whole
+// script back.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig *Migration, file string, script []byte, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction for migration %05d (%s): %v", mig.Index, mig.Name, err)
+	}
+
+	fail := func(err error) error {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	for i, stmt := range splitStatements(string(script)) {
+		if _, err := tx.ExecContext(ctx, stmt.SQL); err != nil {
+			return fail(&MigrationError{
+				Index:     mig.Index,
+				Name:      mig.Name,
+				File:      file,
+				Statement: i + 1,
+				Line:      errorLine(stmt, err),
+				SQL:       stmt.SQL,
+				Err:       err,
+			})
+		}
+	}
+
+	if record != nil {
+		if err := record(tx); err != nil {
+			return fail(fmt.Errorf("could not record migration %05d (%s): %v", mig.Index, mig.Name, err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit migration %05d (%s): %v", mig.Index, mig.Name, err)
+	}
+
+	return nil
+}
+
+// narrows the line of a failed statement down using the character position postgres reports
+func errorLine(stmt statement, err error) int {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Position == "" {
+		return stmt.Line
+	}
+
+	var pos int
+	if _, err := fmt.Sscan(pqErr.Position, &pos); err != nil || pos < 1 {
+		return stmt.Line
+	}
+
+	// position counts characters, not bytes
+	offset := 0
+	for i := 1; i < pos && offset < len(stmt.SQL); i++ {
+		_, size := utf8.DecodeRuneInString(stmt.SQL[offset:])
+		offset += size
+	}
+
+	return stmt.Line + strings.Count(stmt.SQL[:offset], "\n")
+}
+
+// Migrations returns the migration plan in the order it's applied in
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// Status lists every migration on disk as well as applied migrations no longer on disk
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied(context.Background(), m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, mig := range m.migrations {
+		ms := MigrationStatus{Index: mig.Index, Name: mig.Name, Transatory: mig.HasTransatory()}
+		if am, ok := applied[mig.Index]; ok {
+			ms.Applied = true
+			ms.AppliedAt = &am.AppliedAt
+			ms.FinalizedAt = am.FinalizedAt
+			ms.Modified = am.Checksum != mig.Checksum()
+		}
+		statuses = append(statuses, ms)
+	}
+
+	for version, am := range applied {
+		if version < 1 || version > len(m.migrations) {
+			at := am.AppliedAt
+			statuses = append(statuses, MigrationStatus{Index: version, Name: am.Name, Applied: true, AppliedAt: &at, Missing: true})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Index < statuses[j].Index })
+	return statuses, nil
+}
+
+// makes sure every applied migration is still on disk, unchanged since it was applied, and that
+// the applied migrations form an unbroken sequence from the first migration. returns the current
+// version, the index of the last applied migration.
+func (m *Migrator) verify(applied map[int]*AppliedMigration) (int, error) {
+	for version, am := range applied {
+		if version < 1 || version > len(m.migrations) {
+			return 0, fmt.Errorf("migration %05d (%s) was applied but is missing from disk", version, am.Name)
+		}
+		mig := m.migrations[version-1]
+		if sum := mig.Checksum(); sum != am.Checksum {
+			return 0, fmt.Errorf("migration %05d (%s) was modified after it was applied: checksum %s, expected %s", version, mig.Name, sum, am.Checksum)
+		}
+	}
+
+	for i := 1; i <= len(applied); i++ {
+		if _, ok := applied[i]; !ok {
+			return 0, fmt.Errorf("migrations were applied out of order: %05d is not applied", i)
+		}
+	}
+
+	return len(applied), nil
+}
+
+func (m *Migrator) version(ctx context.Context, q querier) (int, error) {
+	version, _, err := m.state(ctx, q)
+	return version, err
+}
+
+// returns the current version and the verified applied migrations, updating the Finished state
+// of every migration along the way
+func (m *Migrator) state(ctx context.Context, q querier) (int, map[int]*AppliedMigration, error) {
+	applied, err := m.applied(ctx, q)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	version, err := m.verify(applied)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for _, mig := range m.migrations {
+		am, ok := applied[mig.Index]
+		mig.Finished = ok && (!mig.HasTransatory() || am.FinalizedAt != nil)
+	}
+
+	return version, applied, nil
+}
+
+// Version returns the index of the last applied migration, 0 when nothing is applied
+func (m *Migrator) Version() (int, error) {
+	return m.version(context.Background(), m.db)
+}
+
+// Up runs the next n pending migrations in ascending order, or every pending migration when n
+// is zero or less, and returns the migrations it applied. it refuses to run anything when an
+// applied migration was modified or removed. a failing migration is rolled back and stops the run,
+// migrations applied before it stay applied.
+func (m *Migrator) Up(n int) ([]*Migration, error) {
+	ctx := context.Background()
+	ran := []*Migration{}
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		ran, err = m.up(ctx, conn, version, n)
+		return err
+	})
+
+	return ran, err
+}
+
+// applies the next n migrations after version, the caller holds the lock
+func (m *Migrator) up(ctx context.Context, conn *sql.Conn, version int, n int) ([]*Migration, error) {
+	ran := []*Migration{}
+
+	pending := m.migrations[version:]
+	if n > 0 && n < len(pending) {
+		pending = pending[:n]
+	}
+
+	for _, mig := range pending {
+		err := m.apply(ctx, conn, mig, "up.sql", mig.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name, checksum) VALUES ($1, $2, $3)", migration_table), mig.Index, mig.Name, mig.Checksum())
+			return err
+		})
+		if err != nil {
+			return ran, err
+		}
+
+		mig.Finished = !mig.HasTransatory()
+		ran = append(ran, mig)
+	}
+
+	return ran, nil
+}
+
+// Down rolls back the last n applied migrations in descending order, or every applied migration
+// when n is zero or less, and returns the migrations it rolled back
+func (m *Migrator) Down(n int) ([]*Migration, error) {
+	ctx := context.Background()
+	ran := []*Migration{}
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		ran, err = m.down(ctx, conn, version, n)
+		return err
+	})
+
+	return ran, err
+}
+
+// rolls back the last n of the migrations up to version, the caller holds the lock
+func (m *Migrator) down(ctx context.Context, conn *sql.Conn, version int, n int) ([]*Migration, error) {
+	ran := []*Migration{}
+
+	if n <= 0 || n > version {
+		n = version
+	}
+
+	for i := version - 1; i >= version-n; i-- {
+		mig := m.migrations[i]
+
+		err := m.apply(ctx, conn, mig, "down.sql", mig.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = $1", migration_table), mig.Index)
+			return err
+		})
+		if err != nil {
+			return ran, err
+		}
+
+		mig.Finished = false
+		ran = append(ran, mig)
+	}
+
+	return ran, nil
+}
+
+// To migrates up or down until the given version is the last applied migration, 0 rolls back
+// everything. returns the migrations applied when migrating up, nil when migrating down. the
+// version is read and migrated from under the same lock, a concurrent migrator can't move it in
+// between.
+func (m *Migrator) To(target int) ([]*Migration, error) {
+	if target < 0 || target > len(m.migrations) {
+		return nil, fmt.Errorf("migration %05d does not exist, the latest is %05d", target, len(m.migrations))
+	}
+
+	ctx := context.Background()
+	var ran []*Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case target > version:
+			ran, err = m.up(ctx, conn, version, target-version)
+		case target < version:
+			_, err = m.down(ctx, conn, version, version-target)
+		}
+		return err
+	})
+
+	return ran, err
+}
+
+// Finalize runs the transatory phase of every applied migration that hasn't ran it yet, in
+// ascending order and each inside its own transaction. this is done after seeding and mostly adds
+// the keys left out of the up migrations. returns the migrations it finalized.
+func (m *Migrator) Finalize() ([]*Migration, error) {
+	ctx := context.Background()
+	ran := []*Migration{}
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, applied, err := m.state(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations[:version] {
+			if !mig.HasTransatory() || applied[mig.Index].FinalizedAt != nil {
+				continue
+			}
+
+			err := m.apply(ctx, conn, mig, "transatory.sql", mig.Transatory, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET finalized_at = NOW() WHERE version = $1", migration_table), mig.Index)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			mig.Finished = true
+			ran = append(ran, mig)
+		}
+
+		return nil
+	})
+
+	return ran, err
+}
+
+// Unfinalize undoes the transatory phase of every finalized migration in descending order by
+// running their transatory_down.sql, leaving the schema ready to be seeded again. returns the
+// migrations it unfinalized.
+func (m *Migrator) Unfinalize() ([]*Migration, error) {
+	ctx := context.Background()
+	ran := []*Migration{}
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, applied, err := m.state(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := version - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if !mig.HasTransatory() || applied[mig.Index].FinalizedAt == nil {
+				continue
+			}
+
+			if len(mig.TransatoryDown) == 0 {
+				return fmt.Errorf("migration %05d (%s) has no transatory_down.sql to undo its transatory phase", mig.Index, mig.Name)
+			}
+
+			err := m.apply(ctx, conn, mig, "transatory_down.sql", mig.TransatoryDown, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET finalized_at = NULL WHERE version = $1", migration_table), mig.Index)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			mig.Finished = false
+			ran = append(ran, mig)
+		}
+
+		return nil
+	})
+
+	return ran, err
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestErrorLine(t *testing.T) {
+	// 15 characters but 18 bytes before the newline, the é are two bytes each
+	stmt := statement{SQL: "SELECT 'ééé', 1\nFROM nowhere\nWHERE x", Line: 3}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"first character", &pq.Error{Position: "1"}, 3},
+		{"on the first line", &pq.Error{Position: "15"}, 3},
+		{"counted in characters, not bytes", &pq.Error{Position: "17"}, 4},
+		{"last line", &pq.Error{Position: "30"}, 5},
+		{"past the end", &pq.Error{Position: "500"}, 5},
+		{"wrapped", fmt.Errorf("migration failed: %w", &pq.Error{Position: "17"}), 4},
+		{"no position", &pq.Error{}, 3},
+		{"unparsable position", &pq.Error{Position: "near"}, 3},
+		{"zero position", &pq.Error{Position: "0"}, 3},
+		{"not from postgres", errors.New("connection reset"), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorLine(stmt, tt.err); got != tt.want {
+				t.Errorf("line %d, expected %d", got, tt.want)
+			}
+		})
+	}
+}
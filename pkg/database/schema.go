@@ -0,0 +1,570 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/* SCHEMA MODEL */
+/****************/
+
+// Schema describes a set of tables once, from which both migration phases are generated. the up
+// phase creates bare tables the seeder can COPY into as fast as possible, the transatory phase adds
+// the identity columns, primary keys and foreign keys once the data is in.
+//
+// tables come in two kinds. lookup tables hold static rows (enum values) and are created complete
+// with their keys, references to them are created immediately. seeded tables are filled by the
+// seeder, their keys and references to other seeded tables are defered to the transatory phase.
+type Schema struct {
+	Tables []*Table
+
+	// tables left out by Introspect and why
+	Skipped map[string]string
+}
+
+type Table struct {
+	Name    string
+	Columns []*Column
+
+	// identity primary key column
+	PrimaryKey string
+
+	References []*Reference
+
+	// composite unique constraints, single column ones are set on the column
+	Unique [][]string
+
+	// rows inserted into a lookup table by the up phase, values of its first non key column
+	Rows []string
+
+	// columns the seeder COPYs into, in the order it writes them. only seeded tables have these.
+	Copy []string
+
+	// table level check constraint expressions
+	Checks []string
+}
+
+type Column struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Unique  bool
+	Default string
+	Check   string
+
+	// maximum length of character types, 0 when unbounded
+	Length int
+
+	// labels of an enum type, in sort order
+	Enum []string
+
+	// the database fills the column itself (identity always or a generated column), it can't be written
+	Generated bool
+}
+
+// a foreign key from Column to the RefColumn of Table
+type Reference struct {
+	Column    string
+	Table     string
+	RefColumn string
+
+	// schema of Table when it's outside the described one, empty otherwise. such a table isn't
+	// described, its rows are only referenced.
+	Schema string
+
+	// the column is left NULL while the tables are filled and set once all of them are, so the
+	// reference doesn't decide the order. set by DeferCycles.
+	Deferred bool
+}
+
+// returns the table with the given name, nil if there is none
+func (s *Schema) Table(name string) *Table {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// returns the tables filled by the seeder in the order they're declared
+func (s *Schema) SeededTables() []*Table {
+	tables := []*Table{}
+	for _, t := range s.Tables {
+		if t.Seeded() {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// whether the table is filled by the seeder as opposed to being a static lookup table
+func (t *Table) Seeded() bool {
+	return len(t.Copy) > 0
+}
+
+// returns the column with the given name, nil if there is none
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Validate makes sure every key, reference, unique constraint and copy column points at a column
+// that exists, and that the tables can be ordered by their references
+func (s *Schema) Validate() error {
+	names := map[string]bool{}
+
+	for _, t := range s.Tables {
+		if names[t.Name] {
+			return fmt.Errorf("schema: duplicate table %s", t.Name)
+		}
+		names[t.Name] = true
+
+		if t.PrimaryKey != "" && t.Column(t.PrimaryKey) == nil {
+			return fmt.Errorf("schema: primary key %s.%s is not a column", t.Name, t.PrimaryKey)
+		}
+
+		if t.Seeded() && len(t.Rows) > 0 {
+			return fmt.Errorf("schema: table %s has both copy columns and lookup rows", t.Name)
+		}
+
+		if len(t.Rows) > 0 && t.valueColumn() == nil {
+			return fmt.Errorf("schema: lookup table %s has no column for its rows", t.Name)
+		}
+
+		for _, name := range t.Copy {
+			if t.Column(name) == nil {
+				return fmt.Errorf("schema: copy column %s.%s is not a column", t.Name, name)
+			}
+		}
+
+		for _, unique := range t.Unique {
+			for _, name := range unique {
+				if t.Column(name) == nil {
+					return fmt.Errorf("schema: unique column %s.%s is not a column", t.Name, name)
+				}
+			}
+		}
+
+		for _, ref := range t.References {
+			if t.Column(ref.Column) == nil {
+				return fmt.Errorf("schema: reference column %s.%s is not a column", t.Name, ref.Column)
+			}
+			if ref.Schema != "" {
+				continue
+			}
+			target := s.Table(ref.Table)
+			if target == nil {
+				return fmt.Errorf("schema: %s.%s references unknown table %s", t.Name, ref.Column, ref.Table)
+			}
+			if target.Column(ref.refColumn()) == nil {
+				return fmt.Errorf("schema: %s.%s references unknown column %s.%s", t.Name, ref.Column, ref.Table, ref.refColumn())
+			}
+		}
+	}
+
+	if _, err := s.Order(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Reference) refColumn() string {
+	if r.RefColumn == "" {
+		return "id"
+	}
+	return r.RefColumn
+}
+
+// the referenced table, qualified with its schema when it's outside the described one
+func (r *Reference) refTable() string {
+	if r.Schema == "" {
+		return r.Table
+	}
+	return r.Schema + "." + r.Table
+}
+
+// whether the reference has to wait for the transatory phase. references to seeded tables do,
+// lookup tables are filled by the up phase so references to them can be created right away.
+func (s *Schema) defered(r *Reference) bool {
+	target := s.Table(r.Table)
+	return target != nil && target.Seeded()
+}
+
+// the column lookup rows are inserted into
+func (t *Table) valueColumn() *Column {
+	for _, c := range t.Columns {
+		if c.Name != t.PrimaryKey {
+			return c
+		}
+	}
+	return nil
+}
+
+/* DDL GENERATION */
+/******************/
+
+// returns the definition of a column in a create table statement
+func (s *Schema) columnDDL(t *Table, c *Column) string {
+	def := c.Name + " " + c.Type
+
+	if c.Name == t.PrimaryKey {
+		if t.Seeded() {
+			// keys of seeded tables are added by the transatory phase, unique keeps ids sane until then
+			return def + " UNIQUE"
+		}
+		return def + " GENERATED ALWAYS AS IDENTITY PRIMARY KEY"
+	}
+
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+	if c.Unique {
+		def += " UNIQUE"
+	}
+	if c.Default != "" {
+		def += " DEFAULT " + c.Default
+	}
+	if c.Check != "" {
+		def += " CHECK (" + c.Check + ")"
+	}
+	return def
+}
+
+// UpDDL returns the create table statements of the up phase. lookup tables are created complete
+// and filled with their rows, seeded tables are created bare.
+func (s *Schema) UpDDL() string {
+	parts := []string{}
+	for _, t := range s.Tables {
+		parts = append(parts, s.tableUpDDL(t))
+	}
+	return strings.Join(parts, "\n\n\n")
+}
+
+func (s *Schema) tableUpDDL(t *Table) string {
+	lines := []string{}
+	for _, c := range t.Columns {
+		lines = append(lines, "\t"+s.columnDDL(t, c))
+	}
+
+	for _, unique := range t.Unique {
+		lines = append(lines, fmt.Sprintf("\tUNIQUE (%s)", strings.Join(unique, ", ")))
+	}
+
+	for _, check := range t.Checks {
+		lines = append(lines, fmt.Sprintf("\tCHECK (%s)", check))
+	}
+
+	for _, ref := range t.References {
+		if !s.defered(ref) {
+			lines = append(lines, fmt.Sprintf("\tFOREIGN KEY (%s) REFERENCES %s (%s)", ref.Column, ref.refTable(), ref.refColumn()))
+		}
+	}
+
+	ddl := fmt.Sprintf("-- %s\nCREATE TABLE IF NOT EXISTS %s (\n%s\n);", t.Name, t.Name, strings.Join(lines, ",\n"))
+
+	if len(t.Rows) > 0 {
+		values := make([]string, len(t.Rows))
+		for i, row := range t.Rows {
+			values[i] = "\t('" + strings.ReplaceAll(row, "'", "''") + "')"
+		}
+		ddl += fmt.Sprintf("\n\nINSERT INTO %s\n\t(%s)\nVALUES\n%s;", t.Name, t.valueColumn().Name, strings.Join(values, ",\n"))
+	}
+
+	return ddl
+}
+
+// DownDDL returns the drop table statements undoing the up phase, in reverse order
+func (s *Schema) DownDDL() string {
+	parts := []string{}
+	for i := len(s.Tables) - 1; i >= 0; i-- {
+		parts = append(parts, fmt.Sprintf("DROP TABLE IF EXISTS %s;", s.Tables[i].Name))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// TransatoryDDL returns the statements of the transatory phase. every seeded table gets its
+// identity column and primary key, its defered foreign keys, and its sequence moved past the
+// seeded ids. tables come in dependency order so a primary key always exists before the foreign
+// keys pointing at it.
+func (s *Schema) TransatoryDDL() string {
+	parts := []string{}
+	for _, t := range s.seededOrder() {
+		actions := []string{}
+		if t.PrimaryKey != "" {
+			actions = append(actions,
+				fmt.Sprintf("\tALTER %s ADD GENERATED ALWAYS AS IDENTITY (START WITH 1)", t.PrimaryKey),
+				fmt.Sprintf("\tADD CONSTRAINT %s_pkey PRIMARY KEY (%s)", t.Name, t.PrimaryKey),
+			)
+		}
+		for _, ref := range t.References {
+			if s.defered(ref) {
+				actions = append(actions, fmt.Sprintf("\tADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)", ref.constraintName(t), ref.Column, ref.refTable(), ref.refColumn()))
+			}
+		}
+		if len(actions) == 0 {
+			continue
+		}
+
+		ddl := fmt.Sprintf("-- %s keys\nALTER TABLE %s\n%s;", t.Name, t.Name, strings.Join(actions, ",\n"))
+		if t.PrimaryKey != "" {
+			ddl += fmt.Sprintf("\n\nSELECT setval(pg_get_serial_sequence('%s', '%s'),\n\t(SELECT MAX(%s) FROM %s));", t.Name, t.PrimaryKey, t.PrimaryKey, t.Name)
+		}
+		parts = append(parts, ddl)
+	}
+	return strings.Join(parts, "\n\n\n")
+}
+
+// TransatoryDownDDL returns the statements undoing the transatory phase, in reverse order so
+// foreign keys are dropped before the keys they point at
+func (s *Schema) TransatoryDownDDL() string {
+	tables := s.seededOrder()
+	parts := []string{}
+	for i := len(tables) - 1; i >= 0; i-- {
+		t := tables[i]
+		actions := []string{}
+		for j := len(t.References) - 1; j >= 0; j-- {
+			ref := t.References[j]
+			if s.defered(ref) {
+				actions = append(actions, fmt.Sprintf("\tDROP CONSTRAINT IF EXISTS %s", ref.constraintName(t)))
+			}
+		}
+		if t.PrimaryKey != "" {
+			actions = append(actions,
+				fmt.Sprintf("\tDROP CONSTRAINT IF EXISTS %s_pkey", t.Name),
+				fmt.Sprintf("\tALTER %s DROP IDENTITY IF EXISTS", t.PrimaryKey),
+			)
+		}
+		if len(actions) == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("-- %s keys\nALTER TABLE %s\n%s;", t.Name, t.Name, strings.Join(actions, ",\n")))
+	}
+	return strings.Join(parts, "\n\n\n")
+}
+
+// foreign keys are named the way postgres names them by default, so constraints created by hand
+// written migrations are found by the generated down script as well
+func (r *Reference) constraintName(t *Table) string {
+	return fmt.Sprintf("%s_%s_fkey", t.Name, r.Column)
+}
+
+/* MIGRATION FILES */
+/*******************/
+
+// Scripts returns every script of a migration generated from the schema, by file name
+func (s *Schema) Scripts() map[string]string {
+	return map[string]string{
+		"up.sql":              s.UpDDL() + "\n",
+		"down.sql":            s.DownDDL() + "\n",
+		"transatory.sql":      s.TransatoryDDL() + "\n",
+		"transatory_down.sql": s.TransatoryDownDDL() + "\n",
+	}
+}
+
+// WriteScripts writes the generated scripts into the migration directory dir
+func (s *Schema) WriteScripts(dir string) error {
+	for file, script := range s.Scripts() {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(script), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckScripts compares the scripts of the migration directory dir with the generated ones and
+// reports every file that's missing or differs, a migration written by hand drifts from the schema
+// description the seeder COPYs by
+func (s *Schema) CheckScripts(dir string) error {
+	differ := []string{}
+	for file, script := range s.Scripts() {
+		bs, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err != nil || !bytes.Equal(bs, []byte(script)) {
+			differ = append(differ, file)
+		}
+	}
+
+	if len(differ) > 0 {
+		sort.Strings(differ)
+		return fmt.Errorf("schema: %s in %s differ from the schema description", strings.Join(differ, ", "), dir)
+	}
+	return nil
+}
+
+/* DEPENDENCY GRAPH */
+/********************/
+
+// CycleError reports tables that reference each other in a loop, so none of them can be filled
+// before the others. Tables holds the loop in reference order, its first table repeated at the end.
+type CycleError struct {
+	Tables []string
+
+	// set by DeferCycles, every table of the loop references the next one with a NOT NULL column
+	NotNull bool
+}
+
+func (e *CycleError) Error() string {
+	if e.NotNull {
+		return fmt.Sprintf("schema: reference cycle %s can't be broken, every reference of it is NOT NULL", strings.Join(e.Tables, " -> "))
+	}
+	return fmt.Sprintf("schema: reference cycle %s", strings.Join(e.Tables, " -> "))
+}
+
+// Order returns the tables sorted so every table comes after the tables it references, keeping
+// the declared order wherever references allow it. references of a table to itself are ignored,
+// any other cycle is reported as a *CycleError.
+func (s *Schema) Order() ([]*Table, error) {
+	deps := s.dependencies()
+
+	ordered := []*Table{}
+	done := map[string]bool{}
+
+	for len(ordered) < len(s.Tables) {
+		progressed := false
+		for _, t := range s.Tables {
+			if done[t.Name] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range deps[t.Name] {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				ordered = append(ordered, t)
+				done[t.Name] = true
+				progressed = true
+			}
+		}
+
+		if !progressed {
+			return nil, &CycleError{Tables: s.cycle(deps, done)}
+		}
+	}
+
+	return ordered, nil
+}
+
+// DeferCycles breaks every reference cycle so the tables can be ordered: in each loop the
+// references of one table to the next are deferred, the first table whose references to the next
+// are all nullable. a loop of NOT NULL references is returned as a *CycleError.
+func (s *Schema) DeferCycles() error {
+	for {
+		_, err := s.Order()
+		var cycle *CycleError
+		if !errors.As(err, &cycle) {
+			return err
+		}
+		if !s.deferCycle(cycle.Tables) {
+			cycle.NotNull = true
+			return cycle
+		}
+	}
+}
+
+// defers the references of one table of the loop to the next, false when every table has a NOT
+// NULL one
+func (s *Schema) deferCycle(loop []string) bool {
+	for i := 0; i+1 < len(loop); i++ {
+		t := s.Table(loop[i])
+
+		refs := []*Reference{}
+		nullable := true
+		for _, ref := range t.References {
+			if ref.Schema != "" || ref.Deferred || ref.Table != loop[i+1] {
+				continue
+			}
+			refs = append(refs, ref)
+			if c := t.Column(ref.Column); c == nil || c.NotNull {
+				nullable = false
+			}
+		}
+
+		if nullable && len(refs) > 0 {
+			for _, ref := range refs {
+				ref.Deferred = true
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// the tables each table references, in the order its references are declared
+func (s *Schema) dependencies() map[string][]string {
+	deps := map[string][]string{}
+	for _, t := range s.Tables {
+		seen := map[string]bool{}
+		for _, ref := range t.References {
+			if ref.Schema == "" && !ref.Deferred && ref.Table != t.Name && s.Table(ref.Table) != nil && !seen[ref.Table] {
+				deps[t.Name] = append(deps[t.Name], ref.Table)
+				seen[ref.Table] = true
+			}
+		}
+	}
+	return deps
+}
+
+// finds a loop among the tables that couldn't be ordered. every one of them waits on another
+// one of them, so following the first unfinished reference of each eventually comes back around.
+func (s *Schema) cycle(deps map[string][]string, done map[string]bool) []string {
+	path := []string{}
+	visited := map[string]int{}
+
+	var current string
+	for _, t := range s.Tables {
+		if !done[t.Name] {
+			current = t.Name
+			break
+		}
+	}
+
+	for {
+		if at, ok := visited[current]; ok {
+			return append(path[at:], current)
+		}
+		visited[current] = len(path)
+		path = append(path, current)
+
+		for _, dep := range deps[current] {
+			if !done[dep] {
+				current = dep
+				break
+			}
+		}
+	}
+}
+
+// the seeded tables in dependency order. Validate reports cycles, the declared order is used if
+// there is one so the scripts can still be printed.
+func (s *Schema) seededOrder() []*Table {
+	ordered, err := s.Order()
+	if err != nil {
+		return s.SeededTables()
+	}
+
+	tables := []*Table{}
+	for _, t := range ordered {
+		if t.Seeded() {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
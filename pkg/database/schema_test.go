@@ -0,0 +1,233 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// a reference of a test table, its column is created along with it
+type tableRef struct {
+	column  string
+	table   string
+	notNull bool
+}
+
+// builds a table with an id key and a column for each of its references
+func testTable(name string, refs ...tableRef) *Table {
+	t := &Table{Name: name, Columns: []*Column{{Name: "id", Type: "bigint"}}, PrimaryKey: "id"}
+	for _, ref := range refs {
+		t.Columns = append(t.Columns, &Column{Name: ref.column, Type: "bigint", NotNull: ref.notNull})
+		t.References = append(t.References, &Reference{Column: ref.column, Table: ref.table})
+	}
+	return t
+}
+
+func tableNames(tables []*Table) []string {
+	names := []string{}
+	for _, t := range tables {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		tables []*Table
+		want   []string
+		cycle  []string
+	}{
+		{
+			name:   "declared order without references",
+			tables: []*Table{testTable("c"), testTable("a"), testTable("b")},
+			want:   []string{"c", "a", "b"},
+		},
+		{
+			name: "referenced tables first",
+			tables: []*Table{
+				testTable("posts", tableRef{"thread_id", "threads", true}),
+				testTable("threads", tableRef{"board_id", "boards", true}),
+				testTable("boards"),
+			},
+			want: []string{"boards", "threads", "posts"},
+		},
+		{
+			name: "declared order where references allow it",
+			tables: []*Table{
+				testTable("accounts"),
+				testTable("posts", tableRef{"account_id", "accounts", true}, tableRef{"thread_id", "threads", true}),
+				testTable("boards"),
+				testTable("threads"),
+			},
+			want: []string{"accounts", "boards", "threads", "posts"},
+		},
+		{
+			name: "references to itself are ignored",
+			tables: []*Table{
+				testTable("posts", tableRef{"reply_to", "posts", false}, tableRef{"thread_id", "threads", true}),
+				testTable("threads"),
+			},
+			want: []string{"threads", "posts"},
+		},
+		{
+			name: "references outside the schema are ignored",
+			tables: []*Table{
+				func() *Table {
+					t := testTable("posts", tableRef{"account_id", "accounts", true})
+					t.References[0].Schema = "auth"
+					return t
+				}(),
+				testTable("accounts"),
+			},
+			want: []string{"posts", "accounts"},
+		},
+		{
+			name: "deferred references are ignored",
+			tables: []*Table{
+				func() *Table {
+					t := testTable("accounts", tableRef{"avatar_id", "media", false})
+					t.References[0].Deferred = true
+					return t
+				}(),
+				testTable("media", tableRef{"account_id", "accounts", true}),
+			},
+			want: []string{"accounts", "media"},
+		},
+		{
+			name: "two tables in a cycle",
+			tables: []*Table{
+				testTable("a", tableRef{"b_id", "b", true}),
+				testTable("b", tableRef{"a_id", "a", true}),
+			},
+			cycle: []string{"a", "b", "a"},
+		},
+		{
+			name: "cycle behind a table waiting on it",
+			tables: []*Table{
+				testTable("a", tableRef{"b_id", "b", true}),
+				testTable("b", tableRef{"c_id", "c", true}),
+				testTable("c", tableRef{"b_id", "b", true}),
+				testTable("d"),
+			},
+			cycle: []string{"b", "c", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Schema{Tables: tt.tables}
+			ordered, err := s.Order()
+
+			if tt.cycle != nil {
+				var cycle *CycleError
+				if !errors.As(err, &cycle) {
+					t.Fatalf("expected a *CycleError, got %v", err)
+				}
+				if !reflect.DeepEqual(cycle.Tables, tt.cycle) {
+					t.Errorf("cycle %v, expected %v", cycle.Tables, tt.cycle)
+				}
+				if cycle.NotNull {
+					t.Error("Order marked the cycle NOT NULL")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tableNames(ordered); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ordered %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeferCycles(t *testing.T) {
+	t.Run("nullable reference deferred", func(t *testing.T) {
+		s := &Schema{Tables: []*Table{
+			testTable("accounts", tableRef{"avatar_id", "media", false}),
+			testTable("media", tableRef{"account_id", "accounts", true}),
+		}}
+		if err := s.DeferCycles(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !s.Table("accounts").References[0].Deferred {
+			t.Error("accounts.avatar_id wasn't deferred")
+		}
+		if s.Table("media").References[0].Deferred {
+			t.Error("the NOT NULL media.account_id was deferred")
+		}
+
+		ordered, err := s.Order()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tableNames(ordered); !reflect.DeepEqual(got, []string{"accounts", "media"}) {
+			t.Errorf("ordered %v once deferred", got)
+		}
+	})
+
+	t.Run("later table of the loop deferred", func(t *testing.T) {
+		s := &Schema{Tables: []*Table{
+			testTable("a", tableRef{"b_id", "b", true}),
+			testTable("b", tableRef{"c_id", "c", true}),
+			testTable("c", tableRef{"a_id", "a", false}, tableRef{"other_a_id", "a", false}),
+		}}
+		if err := s.DeferCycles(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, ref := range s.Table("c").References {
+			if !ref.Deferred {
+				t.Errorf("c.%s wasn't deferred", ref.Column)
+			}
+		}
+		ordered, err := s.Order()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tableNames(ordered); !reflect.DeepEqual(got, []string{"c", "b", "a"}) {
+			t.Errorf("ordered %v once deferred", got)
+		}
+	})
+
+	t.Run("NOT NULL loop", func(t *testing.T) {
+		s := &Schema{Tables: []*Table{
+			testTable("a", tableRef{"b_id", "b", true}),
+			testTable("b", tableRef{"a_id", "a", true}),
+		}}
+		err := s.DeferCycles()
+
+		var cycle *CycleError
+		if !errors.As(err, &cycle) {
+			t.Fatalf("expected a *CycleError, got %v", err)
+		}
+		if !cycle.NotNull || !reflect.DeepEqual(cycle.Tables, []string{"a", "b", "a"}) {
+			t.Errorf("got %+v, expected the NOT NULL loop a -> b -> a", cycle)
+		}
+		if !strings.Contains(err.Error(), "can't be broken") {
+			t.Errorf("error doesn't say the cycle can't be broken: %v", err)
+		}
+		for _, table := range s.Tables {
+			if table.References[0].Deferred {
+				t.Errorf("%s.%s was deferred", table.Name, table.References[0].Column)
+			}
+		}
+	})
+
+	t.Run("without cycles", func(t *testing.T) {
+		s := &Schema{Tables: []*Table{
+			testTable("posts", tableRef{"thread_id", "threads", false}),
+			testTable("threads"),
+		}}
+		if err := s.DeferCycles(); err != nil {
+			t.Fatal(err)
+		}
+		if s.Tables[0].References[0].Deferred {
+			t.Error("a reference outside any cycle was deferred")
+		}
+	})
+}
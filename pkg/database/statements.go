@@ -0,0 +1,140 @@
+package database
+
+import (
+	"strings"
+)
+
+// a single statement of a migration script and the line it starts on
+type statement struct {
+	SQL  string
+	Line int
+}
+
+// splits a sql script into its statements on top level semicolons. semicolons inside quotes,
+// quoted identifiers, dollar quoted bodies and comments are left alone. empty statements and
+// statements consisting only of comments are dropped.
+func splitStatements(script string) []statement {
+	statements := []statement{}
+
+	var current strings.Builder
+	line := 1
+	start := 0
+	hasCode := false
+
+	flush := func() {
+		if hasCode {
+			statements = append(statements, statement{SQL: strings.TrimSpace(current.String()), Line: start})
+		}
+		current.Reset()
+		hasCode = false
+	}
+
+	// marks the start of a statement on the first character that isn't whitespace or a comment,
+	// anything before it is dropped so the statement text starts on the recorded line
+	code := func() {
+		if !hasCode {
+			hasCode = true
+			start = line
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+
+		switch {
+		case c == '\n':
+			line++
+			current.WriteByte(c)
+
+		case c == '-' && i+1 < len(script) && script[i+1] == '-':
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			current.WriteString(script[i : i+end])
+			i += end - 1
+
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 2
+			} else {
+				end += 2
+			}
+			body := script[i : i+2+end]
+			line += strings.Count(body, "\n")
+			current.WriteString(body)
+			i += 1 + end
+
+		case c == '\'' || c == '"':
+			code()
+			end := i + 1
+			for end < len(script) {
+				if script[end] == c {
+					// a doubled quote is an escaped quote
+					if end+1 < len(script) && script[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(script) {
+				end = len(script) - 1
+			}
+			body := script[i : end+1]
+			line += strings.Count(body, "\n")
+			current.WriteString(body)
+			i = end
+
+		case c == '$':
+			code()
+			tag := dollarTag(script[i:])
+			if tag == "" {
+				current.WriteByte(c)
+				continue
+			}
+			end := strings.Index(script[i+len(tag):], tag)
+			if end < 0 {
+				end = len(script) - i - len(tag)
+			} else {
+				end += len(tag)
+			}
+			body := script[i : i+len(tag)+end]
+			line += strings.Count(body, "\n")
+			current.WriteString(body)
+			i += len(tag) + end - 1
+
+		case c == ';':
+			flush()
+
+		default:
+			if c != ' ' && c != '\t' && c != '\r' {
+				code()
+			}
+			current.WriteByte(c)
+		}
+	}
+
+	flush()
+	return statements
+}
+
+// returns the dollar quote tag at the start of s ($$ or $tag$), or an empty string when s doesn't
+// start with one (positional parameters like $1 aren't tags)
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case c >= '0' && c <= '9' && i > 1:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []statement
+	}{
+		{
+			name:   "one per line",
+			script: "CREATE TABLE a (id int);\nCREATE TABLE b (id int);\n",
+			want:   []statement{{"CREATE TABLE a (id int)", 1}, {"CREATE TABLE b (id int)", 2}},
+		},
+		{
+			name:   "without a final semicolon",
+			script: "SELECT 1;\n\nSELECT 2",
+			want:   []statement{{"SELECT 1", 1}, {"SELECT 2", 3}},
+		},
+		{
+			name:   "semicolon in a string",
+			script: "INSERT INTO a VALUES ('x;y', 'it''s; fine');\nSELECT 1;",
+			want:   []statement{{"INSERT INTO a VALUES ('x;y', 'it''s; fine')", 1}, {"SELECT 1", 2}},
+		},
+		{
+			name:   "semicolon in a quoted identifier",
+			script: `CREATE TABLE "odd;name" (id int);`,
+			want:   []statement{{`CREATE TABLE "odd;name" (id int)`, 1}},
+		},
+		{
+			name:   "string over several lines",
+			script: "SELECT 'a\n;\nb';\nSELECT 2;",
+			want:   []statement{{"SELECT 'a\n;\nb'", 1}, {"SELECT 2", 4}},
+		},
+		{
+			name:   "dollar quoted body",
+			script: "CREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql;\nSELECT f();",
+			want: []statement{
+				{"CREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql", 1},
+				{"SELECT f()", 6},
+			},
+		},
+		{
+			name:   "tagged dollar quote holding $$",
+			script: "DO $body$ BEGIN PERFORM '$$;'; END $body$;\nSELECT 1;",
+			want:   []statement{{"DO $body$ BEGIN PERFORM '$$;'; END $body$", 1}, {"SELECT 1", 2}},
+		},
+		{
+			name:   "positional parameters aren't dollar quotes",
+			script: "PREPARE p AS SELECT $1;\nEXECUTE p(1);",
+			want:   []statement{{"PREPARE p AS SELECT $1", 1}, {"EXECUTE p(1)", 2}},
+		},
+		{
+			name:   "leading comments are dropped",
+			script: "-- first; table\n/* a;\n   block */\nCREATE TABLE a (id int);",
+			want:   []statement{{"CREATE TABLE a (id int)", 4}},
+		},
+		{
+			name:   "comments inside a statement are kept",
+			script: "SELECT 1 -- one; two\n, 2;\nSELECT /* ; */ 3;",
+			want:   []statement{{"SELECT 1 -- one; two\n, 2", 1}, {"SELECT /* ; */ 3", 3}},
+		},
+		{
+			name:   "empty and comment only statements are dropped",
+			script: ";;\n-- nothing;\n;\nSELECT 1;\n  ;",
+			want:   []statement{{"SELECT 1", 4}},
+		},
+		{
+			name:   "empty script",
+			script: "",
+			want:   []statement{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDollarTag(t *testing.T) {
+	tests := map[string]string{
+		"$$ body":   "$$",
+		"$fn$ body": "$fn$",
+		"$a_1$":     "$a_1$",
+		"$1":        "",
+		"$1$":       "",
+		"$ a$":      "",
+		"$unclosed": "",
+	}
+	for s, want := range tests {
+		if got := dollarTag(s); got != want {
+			t.Errorf("dollarTag(%q) = %q, expected %q", s, got, want)
+		}
+	}
+}
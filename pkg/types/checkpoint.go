@@ -0,0 +1,297 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+/* CHECKPOINT */
+/**************/
+
+// Checkpoint is the progress of a seeding run, written to a file after every committed chunk so an
+// interrupted run can be resumed. the seed and configuration reproduce the same data, the
+// committed rows and completed tables say what of it is already in the database.
+type Checkpoint struct {
+	Seed   int64            `json:"seed"`
+	Config CheckpointConfig `json:"config"`
+
+	// the profile the run was seeded with, its boards, accounts and weights decide the data too
+	Profile *Profile `json:"profile,omitempty"`
+
+	// id counters once every row is generated, a resumed run has to arrive at the same ones
+	Counters map[string]int `json:"counters,omitempty"`
+
+	// tables whose inserter finished
+	Completed []string `json:"completed"`
+
+	// rows committed per table, when the checkpoint was written. a resumed run counts them again
+	// in the database, the checkpoint may be a chunk behind
+	Committed map[string]int `json:"committed"`
+}
+
+// the parts of the seeder configuration that decide what's generated and how it's inserted, as
+// they are once every config function is applied. a resumed run is configured the same whether the
+// interrupted one was configured by flags, a profile or config functions.
+type CheckpointConfig struct {
+	MinAccountCount   int  `json:"min_account_count"`
+	MaxAccountCount   int  `json:"max_account_count"`
+	MinArticleCount   int  `json:"min_article_count"`
+	MaxArticleCount   int  `json:"max_article_count"`
+	MinThreadPerBoard int  `json:"min_thread_per_board"`
+	MaxThreadPerBoard int  `json:"max_thread_per_board"`
+	MinPostPerThread  int  `json:"min_post_per_thread"`
+	MaxPostPerThread  int  `json:"max_post_per_thread"`
+	Stream            bool `json:"stream"`
+	BatchSize         int  `json:"batch_size"`
+	Workers           int  `json:"workers"`
+
+	// the window the timestamps were generated in
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+
+	// soft-delete rates by table and by status, as account_status.banned
+	DeleteRates       map[string]float64 `json:"delete_rates,omitempty"`
+	StatusDeleteRates map[string]float64 `json:"status_delete_rates,omitempty"`
+
+	// the traffic model threads and replies followed
+	Traffic *Traffic `json:"traffic,omitempty"`
+
+	// how posts were spread over the accounts, threads and boards
+	Popularity map[string]*Popularity `json:"popularity,omitempty"`
+
+	// the boards and accounts every dataset started with, nil in a checkpoint written before they
+	// were recorded
+	Boards   []ProfileBoard   `json:"boards"`
+	Accounts []ProfileAccount `json:"accounts"`
+
+	// the weights the enum values and email domains were picked with, by value
+	Weights *ProfileWeights `json:"weights,omitempty"`
+
+	// the shape of the text of every article and post body
+	Lorem *CheckpointLorem `json:"lorem,omitempty"`
+}
+
+// the lorem configuration of the seeder once its config functions are applied
+type CheckpointLorem struct {
+	MinWordLength      int            `json:"min_word_length"`
+	MaxWordLength      int            `json:"max_word_length"`
+	MinSentenceLength  int            `json:"min_sentence_length"`
+	MaxSentenceLength  int            `json:"max_sentence_length"`
+	MinParagraphLength int            `json:"min_paragraph_length"`
+	MaxParagraphLength int            `json:"max_paragraph_length"`
+	MinParagraphs      int            `json:"min_paragraphs"`
+	MaxParagraphs      int            `json:"max_paragraphs"`
+	CapitalizeFirst    bool           `json:"capitalize_first"`
+	Punctuation        bool           `json:"punctuation"`
+	PunctuationWeights map[string]int `json:"punctuation_weights"`
+}
+
+// reads a checkpoint written by an earlier run
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %v", path, err)
+	}
+
+	if cp.Committed == nil {
+		cp.Committed = map[string]int{}
+	}
+
+	return cp, nil
+}
+
+// writes the checkpoint next to its destination first and moves it in place, a run dying while
+// writing never leaves a broken checkpoint behind
+func (cp *Checkpoint) Save(path string) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// writes the progress of the seeder to the checkpoint file, if it has one. the caller holds s.mu.
+func (s *Seeder) saveCheckpoint() error {
+	if s.Cfg.checkpoint == "" {
+		return nil
+	}
+
+	c := s.Cfg
+	cp := &Checkpoint{
+		Seed: c.seed,
+		Config: CheckpointConfig{
+			MinAccountCount:   c.minAccountCount,
+			MaxAccountCount:   c.maxAccountCount,
+			MinArticleCount:   c.minArticleCount,
+			MaxArticleCount:   c.maxArticleCount,
+			MinThreadPerBoard: c.minThreadPerBoard,
+			MaxThreadPerBoard: c.maxThreadPerBoard,
+			MinPostPerThread:  c.minPostPerThread,
+			MaxPostPerThread:  c.maxPostPerThread,
+			Stream:            c.stream,
+			BatchSize:         c.batchSize,
+			Workers:           c.workers,
+			Since:             c.since,
+			Until:             c.until,
+			DeleteRates:       c.deleteRates,
+			StatusDeleteRates: map[string]float64{},
+			Traffic:           c.traffic,
+			Popularity:        c.popularity,
+			Boards:            []ProfileBoard{},
+			Accounts:          []ProfileAccount{},
+			Weights:           c.weights.profile(),
+			Lorem:             checkpointLorem(c.lorem),
+		},
+		Profile:   c.profile,
+		Counters:  s.counters,
+		Completed: []string{},
+		Committed: s.Committed,
+	}
+
+	for _, b := range c.boards {
+		cp.Config.Boards = append(cp.Config.Boards, ProfileBoard{Title: b[0], Short: b[1], Description: b[2]})
+	}
+	for _, a := range c.accounts {
+		cp.Config.Accounts = append(cp.Config.Accounts, ProfileAccount{Username: a[0], Email: a[1], Role: a[2]})
+	}
+
+	for status, rate := range c.statusDeleteRates {
+		cp.Config.StatusDeleteRates[statusKey(status)] = rate
+	}
+
+	for _, t := range seed_schema.SeededTables() {
+		if s.completed[t.Name] {
+			cp.Completed = append(cp.Completed, t.Name)
+		}
+	}
+
+	return cp.Save(c.checkpoint)
+}
+
+// the id counters by the names they're stored under in the checkpoint
+func (ids entityIDs) snapshot() map[string]int {
+	return map[string]int{
+		"post_id":          ids.post,
+		"post_content_id":  ids.postContent,
+		"identity_id":      ids.identity,
+		"identity_post_id": ids.identityPost,
+	}
+}
+
+// records the id counters once generation is done. when resuming they have to match the ones of
+// the interrupted run, otherwise the data differs and the committed rows can't be continued.
+func (s *Seeder) generated() error {
+	counters := s.ids.snapshot()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cp := s.Cfg.resumed; cp != nil && cp.Counters != nil {
+		for name, value := range cp.Counters {
+			if counters[name] != value {
+				return fmt.Errorf("resumed run generated %s %d, the checkpoint has %d: the data differs from the interrupted run", name, counters[name], value)
+			}
+		}
+	}
+
+	s.counters = counters
+	return s.saveCheckpoint()
+}
+
+// the weights by the names a profile gives the enum values
+func (w *seedWeights) profile() *ProfileWeights {
+	byName := func(weights map[Enum]int) map[string]int {
+		m := make(map[string]int, len(weights))
+		for value, weight := range weights {
+			m[value.String()] = weight
+		}
+		return m
+	}
+
+	return &ProfileWeights{
+		AccountRole:    byName(w.accountRole),
+		AccountStatus:  byName(w.accountStatus),
+		ArticleStatus:  byName(w.articleStatus),
+		ThreadStatus:   byName(w.threadStatus),
+		ThreadRole:     byName(w.threadRole),
+		IdentityStatus: byName(w.identityStatus),
+		EmailDomain:    w.emailDomain,
+	}
+}
+
+// the weights of a checkpoint, a value the enum doesn't know is left out
+func checkpointWeights(pw *ProfileWeights) *seedWeights {
+	enums := profileEnums()
+	byValue := func(key string, weights map[string]int) map[Enum]int {
+		m := make(map[Enum]int, len(weights))
+		for name, weight := range weights {
+			if value, ok := enums[key][name]; ok {
+				m[value] = weight
+			}
+		}
+		return m
+	}
+
+	return &seedWeights{
+		accountRole:    byValue("account_role", pw.AccountRole),
+		accountStatus:  byValue("account_status", pw.AccountStatus),
+		articleStatus:  byValue("article_status", pw.ArticleStatus),
+		threadStatus:   byValue("thread_status", pw.ThreadStatus),
+		threadRole:     byValue("thread_role", pw.ThreadRole),
+		identityStatus: byValue("identity_status", pw.IdentityStatus),
+		emailDomain:    pw.EmailDomain,
+	}
+}
+
+// applies the lorem config functions to the defaults and records the result
+func checkpointLorem(cfg []LoremConfigFunc) *CheckpointLorem {
+	c := defaultLoremConfig()
+	for _, fn := range cfg {
+		c = fn(c)
+	}
+	return &CheckpointLorem{
+		MinWordLength:      c.minWordLength,
+		MaxWordLength:      c.maxWordLength,
+		MinSentenceLength:  c.minSentenceLength,
+		MaxSentenceLength:  c.maxSentenceLength,
+		MinParagraphLength: c.minParagraphLength,
+		MaxParagraphLength: c.maxParagraphLength,
+		MinParagraphs:      c.minParagraphs,
+		MaxParagraphs:      c.maxParagraphs,
+		CapitalizeFirst:    c.capitalizeFirst,
+		Punctuation:        c.punctuation,
+		PunctuationWeights: c.punctuationWeights,
+	}
+}
+
+// sets the lorem configuration recorded in a checkpoint
+func (l *CheckpointLorem) config(c *LoremConfig) *LoremConfig {
+	c.minWordLength = l.MinWordLength
+	c.maxWordLength = l.MaxWordLength
+	c.minSentenceLength = l.MinSentenceLength
+	c.maxSentenceLength = l.MaxSentenceLength
+	c.minParagraphLength = l.MinParagraphLength
+	c.maxParagraphLength = l.MaxParagraphLength
+	c.minParagraphs = l.MinParagraphs
+	c.maxParagraphs = l.MaxParagraphs
+	c.capitalizeFirst = l.CapitalizeFirst
+	c.punctuation = l.Punctuation
+	c.punctuationWeights = map[string]int{}
+	for char, weight := range l.PunctuationWeights {
+		c.punctuationWeights[char] = weight
+	}
+	return c
+}
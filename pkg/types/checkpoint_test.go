@@ -0,0 +1,122 @@
+package types
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.checkpoint.json")
+	workday, err := TrafficModel("workday")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSeeder(nil,
+		SeederCfgSetSeed(7),
+		SeederCfgSetCheckpoint(path),
+		SeederCfgSetAccountCount(3, 9),
+		SeederCfgSetArticleCount(2, 2),
+		SeederCfgSetThreadsPerBoard(1, 4),
+		SeederCfgSetPostsPerThread(5, 10),
+		SeederCfgSetWorkers(2),
+		SeederCfgSetStreaming(true),
+		SeederCfgSetBatchSize(100),
+		SeederCfgSetTimeWindow(time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)),
+		SeederCfgSetDeleteRate("posts", 0.3),
+		SeederCfgSetStatusDeleteRate(AccountStatusSuspended, 0.5),
+		SeederCfgSetTraffic(workday),
+		SeederCfgSetBurstRate(0.1),
+		SeederCfgSetPopularity("accounts", &Popularity{Distribution: "uniform"}),
+		SeederCfgSetPopularity("threads", &Popularity{Distribution: "zipf", Exponent: 1.3}),
+		SeederCfgSetBoards([][]string{{"qa", "qa", "the board the QA suite posts to"}}),
+		SeederCfgSetAccounts([][]string{{"qa_admin", "qa_admin@example.com", "admin"}}),
+		SeederCfgSetAccountRoleWeights(map[AccountRole]int{AccountRoleUser: 9, AccountRoleAdmin: 1}),
+		SeederCfgSetEmailDomainWeights(map[string]int{"example.com": 1}),
+		SeederCfgSetLorem(LoremMaxParagraphCount(2), LoremPunctuation(false), LoremAddPunctuationWeight(";", 3)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Committed["posts"] = 200
+	s.completed["accounts"] = true
+	s.counters = map[string]int{"post_id": 200}
+	if err := s.saveCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Committed["posts"] != 200 || len(cp.Completed) != 1 || cp.Completed[0] != "accounts" {
+		t.Errorf("progress not kept, committed %v and completed %v", cp.Committed, cp.Completed)
+	}
+
+	want := s.Cfg
+	got := SeederCfgResumeFrom(cp)(defaultSeederConfig())
+
+	fields := []struct {
+		name      string
+		got, want any
+	}{
+		{"seed", got.seed, want.seed},
+		{"account count", []int{got.minAccountCount, got.maxAccountCount}, []int{want.minAccountCount, want.maxAccountCount}},
+		{"article count", []int{got.minArticleCount, got.maxArticleCount}, []int{want.minArticleCount, want.maxArticleCount}},
+		{"threads per board", []int{got.minThreadPerBoard, got.maxThreadPerBoard}, []int{want.minThreadPerBoard, want.maxThreadPerBoard}},
+		{"posts per thread", []int{got.minPostPerThread, got.maxPostPerThread}, []int{want.minPostPerThread, want.maxPostPerThread}},
+		{"workers", got.workers, want.workers},
+		{"stream", got.stream, want.stream},
+		{"batch size", got.batchSize, want.batchSize},
+		{"delete rates", got.deleteRates, want.deleteRates},
+		{"status delete rates", got.statusDeleteRates, want.statusDeleteRates},
+		{"traffic", got.traffic, want.traffic},
+		{"popularity", got.popularity, want.popularity},
+		{"boards", got.boards, want.boards},
+		{"accounts", got.accounts, want.accounts},
+		{"weights", got.weights, want.weights},
+		{"lorem", checkpointLorem(got.lorem), checkpointLorem(want.lorem)},
+	}
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.got, f.want) {
+			t.Errorf("%s resumed as %+v, expected %+v", f.name, f.got, f.want)
+		}
+	}
+
+	if !got.since.Equal(want.since) || !got.until.Equal(want.until) {
+		t.Errorf("window resumed as %v to %v, expected %v to %v", got.since, got.until, want.since, want.until)
+	}
+	if !got.resume || got.resumed != cp {
+		t.Error("the config isn't marked as resumed from the checkpoint")
+	}
+	if err := got.Validate(); err != nil {
+		t.Errorf("resumed config is invalid: %v", err)
+	}
+}
+
+func TestGeneratedCounterMismatch(t *testing.T) {
+	s, err := NewSeeder(nil, SeederCfgSetSeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ids.post = 10
+	s.ids.postContent = 10
+
+	s.Cfg.resumed = &Checkpoint{Counters: map[string]int{"post_id": 10, "post_content_id": 10}}
+	if err := s.generated(); err != nil {
+		t.Errorf("matching counters failed: %v", err)
+	}
+
+	s.Cfg.resumed = &Checkpoint{Counters: map[string]int{"post_id": 11}}
+	err = s.generated()
+	if err == nil {
+		t.Fatal("a counter differing from the checkpoint wasn't reported")
+	}
+	if !strings.Contains(err.Error(), "post_id") {
+		t.Errorf("error doesn't name the counter: %v", err)
+	}
+}
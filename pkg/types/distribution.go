@@ -0,0 +1,184 @@
+package types
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+/* DISTRIBUTIONS */
+/*****************/
+
+// Distribution draws ints from a random source: the index of an item for Alias, Zipf and Weighted,
+// a value of a range for Uniform and Normal. a draw is O(1) and uses the source the same way every
+// time, so the same seed draws the same values.
+type Distribution interface {
+	Sample(r *rand.Rand) int
+}
+
+// Uniform draws every int from Min up to Max, excluded, equally often. an empty range draws Min.
+type Uniform struct {
+	Min int
+	Max int
+}
+
+func (u Uniform) Sample(r *rand.Rand) int {
+	if u.Max <= u.Min {
+		return u.Min
+	}
+	return u.Min + r.Intn(u.Max-u.Min)
+}
+
+// Normal draws ints around Mean, most within StdDev of it, kept from Min up to Max, excluded
+type Normal struct {
+	Mean   float64
+	StdDev float64
+	Min    int
+	Max    int
+}
+
+func (n Normal) Sample(r *rand.Rand) int {
+	v := int(math.Round(r.NormFloat64()*n.StdDev + n.Mean))
+	if v >= n.Max {
+		v = n.Max - 1
+	}
+	if v < n.Min {
+		v = n.Min
+	}
+	return v
+}
+
+// Alias draws the index of a weight in proportion to the weight with the alias method: every index
+// gets a slot, a slot is picked uniformly and then either its own index or the one it borrowed the
+// rest of its share from. O(n) to build, O(1) to draw.
+type Alias struct {
+	prob  []float64
+	alias []int
+}
+
+// builds the alias table of the weights, a weight of 0 or less is never drawn
+func NewAlias(weights []float64) *Alias {
+	n := len(weights)
+	total := 0.0
+	for _, w := range weights {
+		if w > 0 {
+			total += w
+		}
+	}
+	if total == 0 {
+		return &Alias{}
+	}
+
+	a := &Alias{prob: make([]float64, n), alias: make([]int, n)}
+
+	// every share scaled so the average is 1, slots below 1 borrow from slots above it. the
+	// indexes are worked through in order, the same weights always build the same table.
+	scaled := make([]float64, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i, w := range weights {
+		if w > 0 {
+			scaled[i] = w * float64(n) / total
+		}
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+
+		a.prob[s] = scaled[s]
+		a.alias[s] = l
+
+		scaled[l] -= 1 - scaled[s]
+		if scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+
+	// what's left is 1 give or take rounding
+	for _, i := range append(small, large...) {
+		a.prob[i] = 1
+		a.alias[i] = i
+	}
+
+	return a
+}
+
+// a random index, -1 when no weight was above 0
+func (a *Alias) Sample(r *rand.Rand) int {
+	if len(a.prob) == 0 {
+		return -1
+	}
+	i := r.Intn(len(a.prob))
+	if r.Float64() < a.prob[i] {
+		return i
+	}
+	return a.alias[i]
+}
+
+// Zipf draws the index ranked k of n, the first being ranked 1, in proportion to 1/k^s. unlike
+// rand.Zipf any s above 0 works, below 1 is a gentle slope.
+type Zipf struct {
+	*Alias
+}
+
+func NewZipf(n int, s float64) *Zipf {
+	weights := make([]float64, n)
+	for k := range weights {
+		weights[k] = math.Pow(float64(k+1), -s)
+	}
+	return &Zipf{NewAlias(weights)}
+}
+
+// Weighted draws the keys of a weight map in proportion to their weight. go randomizes the order
+// of a map so the keys are sorted first, the same seed always draws the same keys.
+type Weighted[T comparable] struct {
+	keys []T
+	dist *Alias
+}
+
+func NewWeighted[T comparable](weights map[T]int) *Weighted[T] {
+	keys := make([]T, 0, len(weights))
+	for k := range weights {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keyLess(keys[i], keys[j]) })
+
+	w := make([]float64, len(keys))
+	for i, k := range keys {
+		w[i] = float64(weights[k])
+	}
+
+	return &Weighted[T]{keys: keys, dist: NewAlias(w)}
+}
+
+// a random index of the sorted keys, -1 when no weight was above 0
+func (w *Weighted[T]) Sample(r *rand.Rand) int {
+	return w.dist.Sample(r)
+}
+
+// a random key, the zero value when no weight was above 0
+func (w *Weighted[T]) Pick(r *rand.Rand) T {
+	var key T
+	if i := w.Sample(r); i >= 0 {
+		key = w.keys[i]
+	}
+	return key
+}
+
+// draws the rank of an item from a distribution and returns the item at that rank
+type ranked struct {
+	order []int
+	dist  Distribution
+}
+
+func (rk ranked) Sample(r *rand.Rand) int {
+	return rk.order[rk.dist.Sample(r)]
+}
@@ -0,0 +1,174 @@
+package types
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+const draws = 200_000
+
+func TestAliasFrequencies(t *testing.T) {
+	weights := []float64{1, 0, 3, 6, 0, -2}
+	a := NewAlias(weights)
+	r := rand.New(rand.NewSource(1))
+
+	counts := make([]int, len(weights))
+	for i := 0; i < draws; i++ {
+		counts[a.Sample(r)]++
+	}
+
+	for i, w := range weights {
+		if w <= 0 {
+			if counts[i] != 0 {
+				t.Errorf("index %d has weight %v but was drawn %d times", i, w, counts[i])
+			}
+			continue
+		}
+		want := w / 10
+		got := float64(counts[i]) / draws
+		if math.Abs(got-want) > 0.01 {
+			t.Errorf("index %d drawn %.4f of the time, expected %.4f", i, got, want)
+		}
+	}
+}
+
+func TestAliasWithoutWeights(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, weights := range [][]float64{nil, {0, 0}, {-1}} {
+		if i := NewAlias(weights).Sample(r); i != -1 {
+			t.Errorf("weights %v drew %d, expected -1", weights, i)
+		}
+	}
+}
+
+func TestUniformBounds(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	u := Uniform{Min: 3, Max: 8}
+
+	seen := map[int]bool{}
+	for i := 0; i < 10_000; i++ {
+		v := u.Sample(r)
+		if v < u.Min || v >= u.Max {
+			t.Fatalf("drew %d outside [%d, %d)", v, u.Min, u.Max)
+		}
+		seen[v] = true
+	}
+	if len(seen) != u.Max-u.Min {
+		t.Errorf("drew %d different values, expected every one of the %d", len(seen), u.Max-u.Min)
+	}
+}
+
+func TestUniformEmptyRange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, u := range []Uniform{{Min: 5, Max: 5}, {Min: 5, Max: 2}} {
+		if v := u.Sample(r); v != u.Min {
+			t.Errorf("%+v drew %d, expected the min", u, v)
+		}
+	}
+}
+
+func TestRandomFromListReachesEveryItem(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	list := []string{"a", "b", "c"}
+
+	fromList := map[string]bool{}
+	fromChoice := map[string]bool{}
+	for i := 0; i < 1_000; i++ {
+		fromList[RandomFromList(r, list)] = true
+		fromChoice[RandomFromChoice(r, list...)] = true
+	}
+
+	for _, item := range list {
+		if !fromList[item] {
+			t.Errorf("RandomFromList never drew %q", item)
+		}
+		if !fromChoice[item] {
+			t.Errorf("RandomFromChoice never drew %q", item)
+		}
+	}
+
+	if v := RandomFromList(r, []string{"only"}); v != "only" {
+		t.Errorf("a single item list drew %q", v)
+	}
+}
+
+func TestNormalClamped(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	n := Normal{Mean: 10, StdDev: 20, Min: 5, Max: 15}
+
+	seen := map[int]bool{}
+	for i := 0; i < 10_000; i++ {
+		v := n.Sample(r)
+		if v < n.Min || v >= n.Max {
+			t.Fatalf("drew %d outside [%d, %d)", v, n.Min, n.Max)
+		}
+		seen[v] = true
+	}
+	if !seen[n.Min] || !seen[n.Max-1] {
+		t.Errorf("the bounds %d and %d weren't both drawn", n.Min, n.Max-1)
+	}
+}
+
+func TestZipfRankOrder(t *testing.T) {
+	z := NewZipf(5, 1)
+	r := rand.New(rand.NewSource(1))
+
+	counts := make([]int, 5)
+	for i := 0; i < draws; i++ {
+		counts[z.Sample(r)]++
+	}
+
+	for k := 1; k < len(counts); k++ {
+		if counts[k] >= counts[k-1] {
+			t.Errorf("rank %d drawn %d times, not less than rank %d drawn %d times", k+1, counts[k], k, counts[k-1])
+		}
+	}
+
+	// 1/H5 of the draws go to the first rank
+	want := 1 / (1 + 1.0/2 + 1.0/3 + 1.0/4 + 1.0/5)
+	if got := float64(counts[0]) / draws; math.Abs(got-want) > 0.01 {
+		t.Errorf("rank 1 drawn %.4f of the time, expected %.4f", got, want)
+	}
+}
+
+func TestWeightedSameSeedSameKeys(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	draw := func(reverse bool) []string {
+		// the map is filled forwards or backwards and go iterates it in a random order anyway
+		weights := map[string]int{}
+		for i := range keys {
+			k := i
+			if reverse {
+				k = len(keys) - 1 - i
+			}
+			weights[keys[k]] = k + 1
+		}
+
+		w := NewWeighted(weights)
+		r := rand.New(rand.NewSource(42))
+		picked := make([]string, 100)
+		for i := range picked {
+			picked[i] = w.Pick(r)
+		}
+		return picked
+	}
+
+	want := draw(false)
+	for run := 0; run < 20; run++ {
+		got := draw(run%2 == 1)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("run %d drew %q at %d, the first run drew %q", run, got[i], i, want[i])
+			}
+		}
+	}
+}
+
+func TestWeightedWithoutWeights(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	if v := NewWeighted(map[string]int{"a": 0}).Pick(r); v != "" {
+		t.Errorf("drew %q without a weight above 0, expected the zero value", v)
+	}
+}
@@ -1,9 +1,8 @@
 package types
 
 import (
+	"math/rand"
 	"strconv"
-
-	gonanoid "github.com/matoous/go-nanoid/v2"
 )
 
 /* LOREM / TEXT GENERATION */
@@ -23,6 +22,10 @@ var (
 	lower_alpha_start rune = 0x61
 	lower_alpha_end   rune = 0x7a
 
+	// a to z, and A to Z
+	lower_alpha = Uniform{Min: int(lower_alpha_start), Max: int(lower_alpha_end) + 1}
+	upper_alpha = Uniform{Min: int(lower_alpha_start - uppercase_bitmask), Max: int(lower_alpha_end-uppercase_bitmask) + 1}
+
 	special_chars = map[string]rune{
 		"SPACE":         0x20,
 		"FULL-QUOTE":    0x22,
@@ -55,6 +58,15 @@ var (
 type Lorem struct {
 	Output string
 	Cfg    *LoremConfig
+
+	rand *rand.Rand
+
+	// the lengths and punctuation drawn from, built from the config once
+	wordLength      Distribution
+	sentenceLength  Distribution
+	paragraphLength Distribution
+	paragraphs      Distribution
+	punctuation     *Weighted[string]
 }
 
 type LoremConfigFunc func(*LoremConfig) *LoremConfig
@@ -124,6 +136,20 @@ func LoremMaxSentenceLength(i int) LoremConfigFunc {
 	}
 }
 
+func LoremMinParagraphLength(i int) LoremConfigFunc {
+	return func(c *LoremConfig) *LoremConfig {
+		c.minParagraphLength = i
+		return c
+	}
+}
+
+func LoremMaxParagraphLength(i int) LoremConfigFunc {
+	return func(c *LoremConfig) *LoremConfig {
+		c.maxParagraphLength = i
+		return c
+	}
+}
+
 func LoremMinParagraphCount(i int) LoremConfigFunc {
 	return func(c *LoremConfig) *LoremConfig {
 		c.minParagraphs = i
@@ -185,14 +211,22 @@ func LoremAddPunctuationWeight(s string, i int) LoremConfigFunc {
 	}
 }
 
-func NewLorem(cfg ...LoremConfigFunc) *Lorem {
+// NewLorem creates a lorem generator drawing from the given random source, so the same source
+// state always produces the same text
+func NewLorem(r *rand.Rand, cfg ...LoremConfigFunc) *Lorem {
 	config := defaultLoremConfig()
 	for _, fn := range cfg {
 		config = fn(config)
 	}
 	return &Lorem{
-		Cfg:    config,
-		Output: "",
+		Cfg:             config,
+		Output:          "",
+		rand:            r,
+		wordLength:      Uniform{Min: config.minWordLength, Max: config.maxWordLength},
+		sentenceLength:  Uniform{Min: config.minSentenceLength, Max: config.maxSentenceLength},
+		paragraphLength: Uniform{Min: config.minParagraphLength, Max: config.maxParagraphLength},
+		paragraphs:      Uniform{Min: config.minParagraphs, Max: config.maxParagraphs},
+		punctuation:     NewWeighted(config.punctuationWeights),
 	}
 }
 
@@ -200,7 +234,7 @@ func NewLorem(cfg ...LoremConfigFunc) *Lorem {
 // the Output field of the Lorem struct so it can be accessed later if needed
 func (l *Lorem) Generate() string {
 	paragraphs := ""
-	paragraphCount := RandomBetween[int](l.Cfg.minParagraphs, l.Cfg.maxParagraphs)
+	paragraphCount := l.paragraphs.Sample(l.rand)
 	for i := 0; i < paragraphCount; i++ {
 		paragraphs += l.paragraph()
 	}
@@ -229,16 +263,16 @@ func (l *Lorem) GenerateWord() string {
 
 func (l *Lorem) word() string {
 	word := ""
-	wordLen := RandomBetween[int](l.Cfg.minWordLength, l.Cfg.maxWordLength)
+	wordLen := l.wordLength.Sample(l.rand)
 	for i := 0; i < wordLen; i++ {
-		word += string(RandomBetween[rune](lower_alpha_start, lower_alpha_end))
+		word += string(rune(lower_alpha.Sample(l.rand)))
 	}
 	return word
 }
 
 func (l *Lorem) sentence() string {
 	sentence := ""
-	wordCount := RandomBetween[int](l.Cfg.minSentenceLength, l.Cfg.maxSentenceLength)
+	wordCount := l.sentenceLength.Sample(l.rand)
 	for i := 0; i < wordCount; i++ {
 		if i > 0 {
 			sentence += string(special_chars["SPACE"])
@@ -249,14 +283,14 @@ func (l *Lorem) sentence() string {
 		sentence = string(rune(sentence[0])-uppercase_bitmask) + sentence[1:]
 	}
 	if l.Cfg.punctuation && len(sentence) > 0 {
-		sentence += RandomWeightedFromMap[string](l.Cfg.punctuationWeights)
+		sentence += l.punctuation.Pick(l.rand)
 	}
 	return sentence
 }
 
 func (l *Lorem) paragraph() string {
 	paragraph := string(special_chars["SPACE"]) + string(special_chars["SPACE"])
-	sentenceCount := RandomBetween[int](l.Cfg.minParagraphLength, l.Cfg.maxParagraphLength)
+	sentenceCount := l.paragraphLength.Sample(l.rand)
 	for i := 0; i < sentenceCount; i++ {
 		paragraph = paragraph + string(special_chars["SPACE"]) + l.sentence()
 	}
@@ -279,22 +313,26 @@ var (
 	article_slug_max_length  int = 25
 )
 
-func slug(charset string, min, max int) string {
-	sluglen := RandomBetween[int](min, max)
-	slug, _ := gonanoid.Generate(charset, sluglen)
-	return slug
+// builds a slug of random length between min and max from the given charset
+func slug(r *rand.Rand, charset string, min, max int) string {
+	sluglen := Uniform{Min: min, Max: max}.Sample(r)
+	bs := make([]byte, sluglen)
+	for i := range bs {
+		bs[i] = charset[r.Intn(len(charset))]
+	}
+	return string(bs)
 }
 
-func NewIdentitySlug() string {
-	return slug(identity_slug_charset, identity_slug_min_length, identity_slug_max_length)
+func NewIdentitySlug(r *rand.Rand) string {
+	return slug(r, identity_slug_charset, identity_slug_min_length, identity_slug_max_length)
 }
 
-func NewThreadSlug() string {
-	return slug(thread_slug_charset, thread_slug_min_length, thread_slug_max_length)
+func NewThreadSlug(r *rand.Rand) string {
+	return slug(r, thread_slug_charset, thread_slug_min_length, thread_slug_max_length)
 }
 
-func NewArticleSlug() string {
-	return slug(article_slug_charset, article_slug_min_length, article_slug_max_length)
+func NewArticleSlug(r *rand.Rand) string {
+	return slug(r, article_slug_charset, article_slug_min_length, article_slug_max_length)
 }
 
 /* NAME/EMAIL GENERATION */
@@ -326,40 +364,43 @@ var (
 		3: 4,  // add a number to the end
 		4: 2,  // add a special character to the end
 	}
+
+	email_domains = NewWeighted(email_domain_weights)
+	uword_steps   = NewWeighted(uword_step_weights)
 )
 
-func safeRandomSpecialChar() rune {
-	return safe_special_chars[RandomBetween[int](0, len(safe_special_chars))]
+func safeRandomSpecialChar(r *rand.Rand) rune {
+	return RandomFromList(r, safe_special_chars)
 }
 
 // called for each letter of a username to generate the entire username
-func uwordStep(current string) string {
-	step := RandomWeightedFromMap[int](uword_step_weights)
+func uwordStep(r *rand.Rand, current string) string {
+	step := uword_steps.Pick(r)
 	word := current
 
 	switch step {
 	case 1:
-		word += string(RandomBetween[rune](lower_alpha_start, lower_alpha_end))
+		word += string(rune(lower_alpha.Sample(r)))
 	case 2:
-		word += string(RandomBetween[rune](lower_alpha_start-uppercase_bitmask, lower_alpha_end-uppercase_bitmask))
+		word += string(rune(upper_alpha.Sample(r)))
 	case 3:
-		word += strconv.Itoa(RandomBetween[int](0, 9))
+		word += strconv.Itoa(r.Intn(10))
 	case 4:
-		word += string(safeRandomSpecialChar())
+		word += string(safeRandomSpecialChar(r))
 	}
 
 	return word
 }
 
-func AddDomainSuffix(u string) string {
-	return u + "@" + RandomWeightedFromMap[string](email_domain_weights)
+func AddDomainSuffix(r *rand.Rand, u string) string {
+	return u + "@" + email_domains.Pick(r)
 }
 
-func NewUsername() string {
-	usernameLen := RandomBetween[int](username_min_length, username_max_length)
+func NewUsername(r *rand.Rand) string {
+	usernameLen := Uniform{Min: username_min_length, Max: username_max_length}.Sample(r)
 	username := ""
 	for i := 0; i < usernameLen; i++ {
-		username = uwordStep(username)
+		username = uwordStep(r, username)
 	}
 	return username
 }
@@ -407,32 +448,39 @@ var (
 		IdentityStatusSuspended: 6,
 		IdentityStatusBanned:    2,
 	}
+
+	enum_account_roles     = NewWeighted(enum_account_role_weights)
+	enum_account_statuses  = NewWeighted(enum_account_status_weights)
+	enum_article_statuses  = NewWeighted(enum_article_status_weights)
+	enum_thread_statuses   = NewWeighted(enum_thread_status_weights)
+	enum_thread_roles      = NewWeighted(enum_thread_role_weights)
+	enum_identity_statuses = NewWeighted(enum_identity_status_weights)
 )
 
-func RandomEnumAccountRole() AccountRole {
-	return RandomWeightedFromMap[Enum](enum_account_role_weights).(AccountRole)
+func RandomEnumAccountRole(r *rand.Rand) AccountRole {
+	return enum_account_roles.Pick(r).(AccountRole)
 }
 
-func RandomEnumAccountStatus() AccountStatus {
-	return RandomWeightedFromMap[Enum](enum_account_status_weights).(AccountStatus)
+func RandomEnumAccountStatus(r *rand.Rand) AccountStatus {
+	return enum_account_statuses.Pick(r).(AccountStatus)
 }
 
-func RandomEnumArticleStatus() ArticleStatus {
-	return RandomWeightedFromMap[Enum](enum_article_status_weights).(ArticleStatus)
+func RandomEnumArticleStatus(r *rand.Rand) ArticleStatus {
+	return enum_article_statuses.Pick(r).(ArticleStatus)
 }
 
-func RandomEnumThreadStatus() ThreadStatus {
-	return RandomWeightedFromMap[Enum](enum_thread_status_weights).(ThreadStatus)
+func RandomEnumThreadStatus(r *rand.Rand) ThreadStatus {
+	return enum_thread_statuses.Pick(r).(ThreadStatus)
 }
 
-func RandomEnumThreadRole() ThreadRole {
-	return RandomWeightedFromMap[Enum](enum_thread_role_weights).(ThreadRole)
+func RandomEnumThreadRole(r *rand.Rand) ThreadRole {
+	return enum_thread_roles.Pick(r).(ThreadRole)
 }
 
-func RandomEnumIdentityStatus() IdentityStatus {
-	return RandomWeightedFromMap[Enum](enum_identity_status_weights).(IdentityStatus)
+func RandomEnumIdentityStatus(r *rand.Rand) IdentityStatus {
+	return enum_identity_statuses.Pick(r).(IdentityStatus)
 }
 
-func RandomEnumIdentityStyle() IdentityStyle {
-	return IdentityStyleID[RandomBetween[int](1, len(IdentityStyleID))]
+func RandomEnumIdentityStyle(r *rand.Rand) IdentityStyle {
+	return IdentityStyleID[Uniform{Min: 1, Max: len(IdentityStyleID) + 1}.Sample(r)]
 }
@@ -1,9 +1,15 @@
 package types
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/lib/pq"
@@ -403,8 +409,14 @@ var (
 	min_post_per_thread = 3
 	max_post_per_thread = 70
 
-	default_board_weight  = 500_000_000
-	default_thread_weight = 500_000_000
+	// number of tables COPYed into at once, each on its own connection
+	default_insert_workers = 4
+
+	// rows buffered per table between the generators and the COPY when streaming
+	stream_buffer = 1024
+
+	// rows COPYed per transaction, 0 copies every table in a single one
+	default_batch_size = 0
 
 	default_boards [][]string = [][]string{
 		{"general", "gen", "general discussion on general topics, generally."},
@@ -429,11 +441,14 @@ var (
 
 func (s *Seeder) PrintResults() {
 	fmt.Print(UnderlinePrint("Results"))
-	fmt.Printf("  - %v Accounts\n", len(s.Accounts))
+	fmt.Printf("  - Seed %v\n", s.Cfg.seed)
+	fmt.Printf("  - Between %v and %v\n", s.Cfg.since.Format(time.RFC3339), s.Cfg.until.Format(time.RFC3339))
+	fmt.Printf("  - %v Accounts (%v deleted)\n", len(s.Accounts), s.deleted["accounts"])
 	fmt.Printf("    - %v Admins\n", len(s.Admins))
 	fmt.Printf("    - %v Moderators\n", len(s.Mods))
 	fmt.Printf("    - %v Users\n", len(s.Accounts)-(len(s.Admins)+len(s.Mods)))
-	fmt.Printf("  - %v Articles\n", len(s.Articles))
+	fmt.Printf("    - the most active 10%% made %.0f%% of the posts\n", s.topPosterShare(0.1)*100)
+	fmt.Printf("  - %v Articles (%v deleted)\n", len(s.Articles), s.deleted["articles"])
 	fmt.Printf("  - %v Boards\n", len(s.Boards))
 
 	total_threads := 0
@@ -445,9 +460,9 @@ func (s *Seeder) PrintResults() {
 		total_posts += board.PostCount
 	}
 
-	fmt.Printf("  - %v Threads in total\n", total_threads)
-	fmt.Printf("  - %v Posts in total\n", total_posts)
-	fmt.Printf("  - %v Identities in total\n", len(s.Identities))
+	fmt.Printf("  - %v Threads in total (%v deleted, %v viral)\n", total_threads, s.deleted["threads"], s.bursts)
+	fmt.Printf("  - %v Posts in total (%v deleted)\n", total_posts, s.deleted["posts"])
+	fmt.Printf("  - %v Identities in total (%v deleted)\n", len(s.Identities)+s.streamed["identities"], s.deleted["identities"])
 
 	fmt.Printf("-------------------------\n")
 }
@@ -463,9 +478,123 @@ type SeederConfig struct {
 	maxThreadPerBoard int
 	minPostPerThread  int
 	maxPostPerThread  int
+
+	// the random source for every generator is seeded with this, the same seed reproduces the same dataset
+	seed int64
+
+	// maximum number of inserters running at once
+	workers int
+
+	// send posts, post contents, identities and identity posts straight to their COPY instead of
+	// collecting them first
+	stream bool
+
+	// rows COPYed and committed per transaction, 0 for a single transaction per table
+	batchSize int
+
+	// skip the rows an earlier run with the same seed already committed
+	resume bool
+
+	// file the progress is written to after every committed chunk, empty for none
+	checkpoint string
+
+	// checkpoint of the interrupted run being resumed
+	resumed *Checkpoint
+
+	// called after every committed chunk
+	progress ProgressFunc
+
+	// boards and accounts created before any generated ones, as title, short, description and
+	// username, email, role
+	boards   [][]string
+	accounts [][]string
+
+	// weights of the generated enum values and email domains
+	weights *seedWeights
+
+	// applied to the lorem generator of every article and post body
+	lorem []LoremConfigFunc
+
+	// the profile the configuration was loaded from, kept for the checkpoint
+	profile *Profile
+
+	// every generated timestamp falls between these
+	since time.Time
+	until time.Time
+
+	// share of the rows soft-deleted per table, and per status for rows in one
+	deleteRates       map[string]float64
+	statusDeleteRates map[Enum]float64
+
+	// when threads are opened and replied to
+	traffic *Traffic
+
+	// how posts are spread over the accounts, threads and boards
+	popularity map[string]*Popularity
+
+	// set by a config function that couldn't be applied, returned by NewSeeder
+	err error
+}
+
+type seedWeights struct {
+	accountRole    map[Enum]int
+	accountStatus  map[Enum]int
+	articleStatus  map[Enum]int
+	threadStatus   map[Enum]int
+	threadRole     map[Enum]int
+	identityStatus map[Enum]int
+	emailDomain    map[string]int
+}
+
+func defaultSeedWeights() *seedWeights {
+	return &seedWeights{
+		accountRole:    enum_account_role_weights,
+		accountStatus:  enum_account_status_weights,
+		articleStatus:  enum_article_status_weights,
+		threadStatus:   enum_thread_status_weights,
+		threadRole:     enum_thread_role_weights,
+		identityStatus: enum_identity_status_weights,
+		emailDomain:    email_domain_weights,
+	}
+}
+
+// the weights built into distributions to draw from, once the configuration is validated
+type seedDistributions struct {
+	accountRole    *Weighted[Enum]
+	accountStatus  *Weighted[Enum]
+	articleStatus  *Weighted[Enum]
+	threadStatus   *Weighted[Enum]
+	threadRole     *Weighted[Enum]
+	identityStatus *Weighted[Enum]
+	emailDomain    *Weighted[string]
+}
+
+func (w *seedWeights) distributions() *seedDistributions {
+	return &seedDistributions{
+		accountRole:    NewWeighted(w.accountRole),
+		accountStatus:  NewWeighted(w.accountStatus),
+		articleStatus:  NewWeighted(w.articleStatus),
+		threadStatus:   NewWeighted(w.threadStatus),
+		threadRole:     NewWeighted(w.threadRole),
+		identityStatus: NewWeighted(w.identityStatus),
+		emailDomain:    NewWeighted(w.emailDomain),
+	}
+}
+
+// reports that done of total rows of a table are committed, total is -1 for streamed tables
+type ProgressFunc func(table string, done int, total int)
+
+// prints a line per committed chunk
+func printProgress(table string, done int, total int) {
+	if total < 0 {
+		fmt.Printf("  - %v %v rows\n", table, done)
+		return
+	}
+	fmt.Printf("  - %v %v/%v rows\n", table, done, total)
 }
 
 func defaultSeederConfig() *SeederConfig {
+	since, until := DefaultTimeWindow()
 	return &SeederConfig{
 		minAccountCount:   min_account_count,
 		maxAccountCount:   max_account_count,
@@ -475,9 +604,486 @@ func defaultSeederConfig() *SeederConfig {
 		maxThreadPerBoard: max_thread_per_board,
 		minPostPerThread:  min_post_per_thread,
 		maxPostPerThread:  max_post_per_thread,
+		seed:              time.Now().UnixNano(),
+		workers:           default_insert_workers,
+		batchSize:         default_batch_size,
+		progress:          printProgress,
+		boards:            default_boards,
+		accounts:          default_accounts,
+		weights:           defaultSeedWeights(),
+		since:             since,
+		until:             until,
+		deleteRates:       default_delete_rates,
+		statusDeleteRates: default_status_delete_rates,
+		traffic:           traffic_models[default_traffic_model],
+		popularity:        default_popularity,
+	}
+}
+
+/* SEEDER CONFIGURATION FUNCTIONS */
+/**********************************/
+
+// sets the seed of the random source used by every generator. seeding twice with the same value
+// and configuration produces identical data.
+func SeederCfgSetSeed(i int64) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.seed = i
+		return c
+	}
+}
+
+// sets how many tables are COPYed into at once, 1 inserts them one after the other
+func SeederCfgSetWorkers(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.workers = i
+		return c
+	}
+}
+
+// streams the posts, post contents, identities and identity posts into their tables while they're
+// generated, memory stays bounded no matter how many posts are seeded
+func SeederCfgSetStreaming(b bool) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.stream = b
+		return c
+	}
+}
+
+// splits every COPY into chunks of i rows that are committed on their own, so a failure only
+// loses the chunk it happened in. 0 copies every table in a single transaction.
+func SeederCfgSetBatchSize(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.batchSize = i
+		return c
+	}
+}
+
+// continues an interrupted run. the rows already in each seeded table are the chunks the earlier
+// run committed, they're generated again but not inserted. only meaningful with the seed and
+// configuration of the interrupted run.
+func SeederCfgSetResume(b bool) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.resume = b
+		return c
+	}
+}
+
+// writes a checkpoint of the run to the file at path after every committed chunk
+func SeederCfgSetCheckpoint(path string) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.checkpoint = path
+		return c
+	}
+}
+
+// resumes the run the checkpoint was written by. its seed and configuration replace the ones set
+// so far so the same data is generated, the tables it completed are skipped and the others
+// continue after their last committed chunk.
+func SeederCfgResumeFrom(cp *Checkpoint) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		if cp.Profile != nil {
+			c = SeederCfgSetProfile(cp.Profile)(c)
+		}
+		c.seed = cp.Seed
+		c.minAccountCount = cp.Config.MinAccountCount
+		c.maxAccountCount = cp.Config.MaxAccountCount
+		c.minArticleCount = cp.Config.MinArticleCount
+		c.maxArticleCount = cp.Config.MaxArticleCount
+		c.minThreadPerBoard = cp.Config.MinThreadPerBoard
+		c.maxThreadPerBoard = cp.Config.MaxThreadPerBoard
+		c.minPostPerThread = cp.Config.MinPostPerThread
+		c.maxPostPerThread = cp.Config.MaxPostPerThread
+		c.stream = cp.Config.Stream
+		c.batchSize = cp.Config.BatchSize
+		if cp.Config.Workers > 0 {
+			c.workers = cp.Config.Workers
+		}
+		if !cp.Config.Until.IsZero() {
+			c.since = cp.Config.Since
+			c.until = cp.Config.Until
+		}
+		if cp.Config.Traffic != nil {
+			c.traffic = cp.Config.Traffic
+		}
+		// set one by one, a checkpoint missing one keeps the one configured so far
+		for of, p := range cp.Config.Popularity {
+			c = SeederCfgSetPopularity(of, p)(c)
+		}
+		if cp.Config.Boards != nil {
+			c.boards = [][]string{}
+			for _, b := range cp.Config.Boards {
+				c.boards = append(c.boards, []string{b.Title, b.Short, b.Description})
+			}
+		}
+		if cp.Config.Accounts != nil {
+			c.accounts = [][]string{}
+			for _, a := range cp.Config.Accounts {
+				c.accounts = append(c.accounts, []string{a.Username, a.Email, a.Role})
+			}
+		}
+		if cp.Config.Weights != nil {
+			c.weights = checkpointWeights(cp.Config.Weights)
+		}
+		if cp.Config.Lorem != nil {
+			c.lorem = []LoremConfigFunc{cp.Config.Lorem.config}
+		}
+		if cp.Config.DeleteRates != nil {
+			c.deleteRates = cp.Config.DeleteRates
+			c.statusDeleteRates = map[Enum]float64{}
+			for key, rate := range cp.Config.StatusDeleteRates {
+				if status, ok := parseStatusKey(key); ok {
+					c.statusDeleteRates[status] = rate
+				}
+			}
+		}
+		c.resume = true
+		c.resumed = cp
+		return c
+	}
+}
+
+// replaces the progress report printed after every committed chunk, nil silences it
+func SeederCfgSetProgress(fn ProgressFunc) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.progress = fn
+		return c
+	}
+}
+
+// the count ranges are drawn from with the upper bound excluded, a range of 10 to 20 generates 10
+// to 19 and a range of 10 to 10 always 10. the range setters set both bounds at once.
+//
+// posts per thread is an average per board: every board draws a count from the range and gets its
+// threads times that many replies, which the popularity of the threads spreads over them. a single
+// thread can get many more or none at all.
+
+func SeederCfgSetAccountCount(min int, max int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.minAccountCount = min
+		c.maxAccountCount = max
+		return c
+	}
+}
+
+func SeederCfgSetArticleCount(min int, max int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.minArticleCount = min
+		c.maxArticleCount = max
+		return c
+	}
+}
+
+func SeederCfgSetThreadsPerBoard(min int, max int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.minThreadPerBoard = min
+		c.maxThreadPerBoard = max
+		return c
+	}
+}
+
+func SeederCfgSetPostsPerThread(min int, max int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.minPostPerThread = min
+		c.maxPostPerThread = max
+		return c
+	}
+}
+
+func SeederCfgSetMinAccountCount(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.minAccountCount = i
+		return c
+	}
+}
+
+func SeederCfgSetMaxAccountCount(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.maxAccountCount = i
+		return c
+	}
+}
+
+func SeederCfgSetMinArticleCount(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.minArticleCount = i
+		return c
+	}
+}
+
+func SeederCfgSetMaxArticleCount(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.maxArticleCount = i
+		return c
+	}
+}
+
+func SeederCfgSetMinThreadPerBoard(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.minThreadPerBoard = i
+		return c
+	}
+}
+
+func SeederCfgSetMaxThreadPerBoard(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.maxThreadPerBoard = i
+		return c
+	}
+}
+
+// the lowest average of replies per thread a board can draw, not a minimum of any one thread
+func SeederCfgSetMinPostPerThread(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.minPostPerThread = i
+		return c
+	}
+}
+
+// the highest average of replies per thread a board can draw, excluded, not a maximum of any one
+// thread
+func SeederCfgSetMaxPostPerThread(i int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.maxPostPerThread = i
+		return c
+	}
+}
+
+// replaces the boards every dataset starts with, each as title, short and description
+func SeederCfgSetBoards(boards [][]string) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.boards = boards
+		return c
+	}
+}
+
+// replaces the accounts created before the generated ones, each as username, email and role
+func SeederCfgSetAccounts(accounts [][]string) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.accounts = accounts
+		return c
+	}
+}
+
+// applied to the lorem generator of every article and post body, after the ones given before
+func SeederCfgSetLorem(cfg ...LoremConfigFunc) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.lorem = append(c.lorem, cfg...)
+		return c
 	}
 }
 
+// the weight setters replace the weights the values of an enum are picked with. a copy of the
+// weights is made first so the defaults shared by every seeder are never changed.
+
+func SeederCfgSetAccountRoleWeights(weights map[AccountRole]int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.weights = c.weights.with(func(w *seedWeights) { w.accountRole = enumWeights(weights) })
+		return c
+	}
+}
+
+func SeederCfgSetAccountStatusWeights(weights map[AccountStatus]int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.weights = c.weights.with(func(w *seedWeights) { w.accountStatus = enumWeights(weights) })
+		return c
+	}
+}
+
+func SeederCfgSetArticleStatusWeights(weights map[ArticleStatus]int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.weights = c.weights.with(func(w *seedWeights) { w.articleStatus = enumWeights(weights) })
+		return c
+	}
+}
+
+func SeederCfgSetThreadStatusWeights(weights map[ThreadStatus]int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.weights = c.weights.with(func(w *seedWeights) { w.threadStatus = enumWeights(weights) })
+		return c
+	}
+}
+
+func SeederCfgSetThreadRoleWeights(weights map[ThreadRole]int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.weights = c.weights.with(func(w *seedWeights) { w.threadRole = enumWeights(weights) })
+		return c
+	}
+}
+
+func SeederCfgSetIdentityStatusWeights(weights map[IdentityStatus]int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.weights = c.weights.with(func(w *seedWeights) { w.identityStatus = enumWeights(weights) })
+		return c
+	}
+}
+
+func SeederCfgSetEmailDomainWeights(weights map[string]int) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.weights = c.weights.with(func(w *seedWeights) { w.emailDomain = weights })
+		return c
+	}
+}
+
+func (w *seedWeights) with(fn func(*seedWeights)) *seedWeights {
+	copied := *w
+	fn(&copied)
+	return &copied
+}
+
+// enums that can key a map
+type weightedEnum interface {
+	comparable
+	Enum
+}
+
+func enumWeights[T weightedEnum](weights map[T]int) map[Enum]int {
+	m := make(map[Enum]int, len(weights))
+	for k, v := range weights {
+		m[k] = v
+	}
+	return m
+}
+
+/* SEEDER CONFIGURATION VALIDATION */
+/***********************************/
+
+// Validate reports every setting the seeder can't generate data with, all at once
+func (c *SeederConfig) Validate() error {
+	var errs []error
+	if c.err != nil {
+		errs = append(errs, c.err)
+	}
+
+	ranges := []struct {
+		name     string
+		min, max int
+	}{
+		{"accounts", c.minAccountCount, c.maxAccountCount},
+		{"articles", c.minArticleCount, c.maxArticleCount},
+		{"threads per board", c.minThreadPerBoard, c.maxThreadPerBoard},
+		{"posts per thread", c.minPostPerThread, c.maxPostPerThread},
+	}
+	for _, r := range ranges {
+		if err := checkCountRange(r.name, r.min, r.max, 0); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	lorem := defaultLoremConfig()
+	for _, fn := range c.lorem {
+		lorem = fn(lorem)
+	}
+	lorems := []struct {
+		name     string
+		min, max int
+	}{
+		{"lorem word length", lorem.minWordLength, lorem.maxWordLength},
+		{"lorem sentence length", lorem.minSentenceLength, lorem.maxSentenceLength},
+		{"lorem paragraph length", lorem.minParagraphLength, lorem.maxParagraphLength},
+		{"lorem paragraphs", lorem.minParagraphs, lorem.maxParagraphs},
+	}
+	for _, r := range lorems {
+		if err := checkCountRange(r.name, r.min, r.max, 1); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if !c.until.After(c.since) {
+		errs = append(errs, fmt.Errorf("the time window has to end after it starts, got %v to %v", c.since, c.until))
+	}
+
+	errs = append(errs, checkDeleteRates(c.deleteRates, c.statusDeleteRates)...)
+
+	if c.traffic == nil {
+		errs = append(errs, fmt.Errorf("traffic: no traffic model is set"))
+	} else if err := c.traffic.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("traffic: %w", err))
+	}
+
+	errs = append(errs, checkPopularity(c.popularity)...)
+
+	if c.workers < 1 {
+		errs = append(errs, fmt.Errorf("workers must be at least 1, got %d", c.workers))
+	}
+	if c.batchSize < 0 {
+		errs = append(errs, fmt.Errorf("batch size can't be negative, got %d, use 0 for one transaction per table", c.batchSize))
+	}
+
+	weights := []struct {
+		name    string
+		weights map[Enum]int
+	}{
+		{"account role", c.weights.accountRole},
+		{"account status", c.weights.accountStatus},
+		{"article status", c.weights.articleStatus},
+		{"thread status", c.weights.threadStatus},
+		{"thread role", c.weights.threadRole},
+		{"identity status", c.weights.identityStatus},
+	}
+	for _, w := range weights {
+		if err := checkWeightTotal(w.name, w.weights); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := checkWeightTotal("email domain", c.weights.emailDomain); err != nil {
+		errs = append(errs, err)
+	}
+
+	for i, b := range c.boards {
+		if len(b) != 3 {
+			errs = append(errs, fmt.Errorf("board %d needs a title, short and description, got %d values", i, len(b)))
+		}
+	}
+
+	admins := 0
+	for i, a := range c.accounts {
+		if len(a) != 3 {
+			errs = append(errs, fmt.Errorf("account %d needs a username, email and role, got %d values", i, len(a)))
+			continue
+		}
+		switch AccountRole(a[2]) {
+		case AccountRoleAdmin, AccountRoleSuper:
+			admins++
+		case AccountRoleUser, AccountRoleModerator:
+		default:
+			errs = append(errs, fmt.Errorf("account %d (%s) has unknown role %q", i, a[0], a[2]))
+		}
+	}
+	if len(c.accounts)+c.minAccountCount < 1 {
+		errs = append(errs, fmt.Errorf("threads and posts need at least one account, there are no accounts and min accounts is %d", c.minAccountCount))
+	}
+	if c.maxArticleCount > 1 && admins == 0 {
+		errs = append(errs, fmt.Errorf("articles are written by admins, at least one of the accounts needs the admin or super role"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// counts are drawn from [min, max), the range has to hold at least one number
+func checkCountRange(name string, min int, max int, lowest int) error {
+	if min < lowest {
+		return fmt.Errorf("min %s must be at least %d, got %d", name, lowest, min)
+	}
+	if max < min {
+		return fmt.Errorf("max %s (%d) can't be below min %s (%d), the max is excluded unless it equals the min", name, max, name, min)
+	}
+	return nil
+}
+
+func checkWeightTotal[T comparable](name string, weights map[T]int) error {
+	total := 0
+	for value, weight := range weights {
+		if weight < 0 {
+			return fmt.Errorf("%s weight of %v can't be negative, got %d", name, value, weight)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("%s weights need at least one value with a weight above 0", name)
+	}
+	return nil
+}
+
 func defaultSeeder(s *Store) *Seeder {
 	return &Seeder{
 		Store: s,
@@ -500,10 +1106,13 @@ func defaultSeeder(s *Store) *Seeder {
 		Posts:       []*Post{},
 		PostContent: []*PostContent{},
 
-		BoardWeights: map[int]int{},
-		BoardIDMap:   map[int]*Board{},
+		BoardIDMap: map[int]*Board{},
 
 		identityHeapIndex: map[int]map[int]*Identity{},
+		streamed:          map[string]int{},
+		deleted:           map[string]int{},
+		Committed:         map[string]int{},
+		completed:         map[string]bool{},
 	}
 }
 
@@ -511,6 +1120,9 @@ type Seeder struct {
 	Store *Store
 	Cfg   *SeederConfig
 
+	// every generator draws from this source, it's seeded from Cfg when the seeder is created
+	Rand *rand.Rand
+
 	Accounts []*Account
 	Boards   []*Board
 
@@ -528,85 +1140,360 @@ type Seeder struct {
 	Admins []*Account
 	Mods   []*Account
 
-	BoardIDMap   map[int]*Board
-	BoardWeights map[int]int
+	BoardIDMap map[int]*Board
 
 	// thread_id -> account_id (if exist in thread)
 	identityHeapIndex map[int]map[int]*Identity
+
+	// set while streaming, the generated rows go here instead of the slices above
+	streams *rowStreams
+
+	// number of rows sent to each streamed table
+	streamed map[string]int
+
+	// number of rows soft-deleted in each table
+	deleted map[string]int
+
+	// number of threads that went viral
+	bursts int
+
+	// draw the generated enum values and email domains
+	dists *seedDistributions
+
+	// draws the account making each thread and post by its activity
+	activity Distribution
+
+	// rows committed per table, including the ones skipped when resuming
+	Committed map[string]int
+	mu        sync.Mutex
+
+	// tables whose inserter finished
+	completed map[string]bool
+
+	// last id handed out to the entities generated on the fly
+	ids entityIDs
+
+	// id counters once everything is generated
+	counters map[string]int
+}
+
+// the id counters of a seeder. they're per instance so seeders running side by side, or one
+// after the other in the same process, each number their rows from 1.
+type entityIDs struct {
+	post         int
+	postContent  int
+	identity     int
+	identityPost int
 }
 
-func NewSeeder(s *Store, cfg ...SeederConfigFunc) *Seeder {
+// NewSeeder applies the config functions in order over the defaults and returns an error naming
+// every setting that can't be seeded with
+func NewSeeder(s *Store, cfg ...SeederConfigFunc) (*Seeder, error) {
 	seeder := defaultSeeder(s)
 	for _, f := range cfg {
 		seeder.Cfg = f(seeder.Cfg)
 	}
 
-	return seeder
+	if err := seeder.Cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid seeder config: %w", err)
+	}
+
+	seeder.Rand = rand.New(rand.NewSource(seeder.Cfg.seed))
+	seeder.dists = seeder.Cfg.weights.distributions()
+
+	return seeder, nil
 }
 
 type InsertService string
 
 const (
-	StatementExecError     InsertService = "statement execution"
-	StatementClosureError  InsertService = "statement closure"
-	TransactionCommitError InsertService = "transaction commit"
+	TransactionBeginError    InsertService = "transaction begin"
+	StatementPrepareError    InsertService = "statement prepare"
+	StatementExecError       InsertService = "statement execution"
+	CheckpointWriteError     InsertService = "checkpoint write"
+	StatementClosureError    InsertService = "statement closure"
+	TransactionCommitError   InsertService = "transaction commit"
+	TransactionRollbackError InsertService = "transaction rollback"
+	CancellationError        InsertService = "cancellation"
 )
 
 type SeedDBError struct {
 	Model   string
 	Service InsertService
 	Message string
+
+	// index of the offending row in the table, -1 when the failure isn't about a single row
+	Row int
+
+	// the underlying error, a *pq.Error when postgres reported it
+	Err error
+}
+
+func newSeedDBError(model string, service InsertService, row int, err error) *SeedDBError {
+	return &SeedDBError{Model: model, Service: service, Message: err.Error(), Row: row, Err: err}
 }
 
 func (e SeedDBError) Error() string {
-	return fmt.Sprintf("%s failure durring %s insert: %s", e.Service, e.Model, e.Message)
+	msg := fmt.Sprintf("%s failure durring %s insert", e.Service, e.Model)
+	if e.Row >= 0 {
+		msg += fmt.Sprintf(" at row %d", e.Row)
+	}
+	msg += ": " + e.Message
+
+	var pqErr *pq.Error
+	if errors.As(e.Err, &pqErr) {
+		details := []string{"code " + string(pqErr.Code)}
+		if pqErr.Constraint != "" {
+			details = append(details, "constraint "+pqErr.Constraint)
+		}
+		if pqErr.Detail != "" {
+			details = append(details, "detail: "+pqErr.Detail)
+		}
+		msg += " (" + strings.Join(details, ", ") + ")"
+	}
+	return msg
+}
+
+func (e SeedDBError) Unwrap() error {
+	return e.Err
+}
+
+var copy_line_pattern = regexp.MustCompile(`COPY \w+, line (\d+)`)
+
+// postgres checks COPY rows as they stream in and reports a bad one on a later Exec or when the
+// statement is closed. the context of its error names the line of the COPY that failed, which is
+// the offending row counted from the start of the chunk.
+func copyErrorRow(err error, chunkStart int, fallback int) int {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return fallback
+	}
+
+	m := copy_line_pattern.FindStringSubmatch(pqErr.Where)
+	if m == nil {
+		return fallback
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	return chunkStart + line - 1
+}
+
+// rolls the transaction back after a failure. if that fails too the rollback failure is reported,
+// still wrapping the original error.
+func rollback(tx *sql.Tx, failure *SeedDBError) *SeedDBError {
+	err := tx.Rollback()
+	if err == nil || errors.Is(err, sql.ErrTxDone) {
+		return failure
+	}
+
+	return &SeedDBError{
+		Model:   failure.Model,
+		Service: TransactionRollbackError,
+		Message: fmt.Sprintf("%v, after %v", err, failure),
+		Row:     failure.Row,
+		Err:     errors.Join(failure, err),
+	}
 }
 
-func finalizeTransaction(mod string, tx *sql.Tx, stmt *sql.Stmt) *SeedDBError {
+// closes the statement, which flushes the COPY, and commits the transaction. it's rolled back when
+// closing fails. chunkStart is the index of the first row of the chunk, to locate a bad row.
+func finalizeTransaction(mod string, tx *sql.Tx, stmt *sql.Stmt, chunkStart int) *SeedDBError {
 	err := stmt.Close()
 	if err != nil {
-		return &SeedDBError{Model: mod, Service: StatementClosureError, Message: err.Error()}
+		return rollback(tx, newSeedDBError(mod, StatementClosureError, copyErrorRow(err, chunkStart, -1), err))
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return &SeedDBError{Model: mod, Service: TransactionCommitError, Message: err.Error()}
+		return newSeedDBError(mod, TransactionCommitError, copyErrorRow(err, chunkStart, -1), err)
 	}
 
 	return nil
 }
 
-type SeedFunc func() *SeedDBError
+type SeedFunc func(ctx context.Context) *SeedDBError
+
+// Seed generates the dataset and COPYs it into the database. cancelling the context stops the
+// generators and the COPYs, between chunks as well as in the middle of one, the chunk being
+// copied is rolled back.
+func (s *Seeder) Seed(ctx context.Context) error {
+	if s.Cfg.resume {
+		if err := s.loadCommitted(); err != nil {
+			return err
+		}
+	}
+
+	if s.Cfg.stream {
+		return s.seedStreaming(ctx)
+	}
 
-func (s *Seeder) Seed() {
 	fmt.Println("Generating data...")
 
 	s.seedAccounts()
 	s.seedBoards()
 	s.seedArticles()
-	s.seedThreads()
-	s.seedPosts()
+
+	if err := s.seedThreads(ctx); err != nil {
+		return err
+	}
+
+	if err := s.seedPosts(ctx); err != nil {
+		return err
+	}
+
+	s.seedDeletions()
+
+	if err := s.generated(); err != nil {
+		return err
+	}
 
 	fmt.Println("Batching queries...")
 
-	inserters := []SeedFunc{
-		s.insertAccounts,
-		s.insertBoards,
-		s.insertArticleContent,
-		s.insertArticles,
-		s.insertThreads,
-		s.insertPostContent,
-		s.insertPosts,
-		s.insertIdentities,
-		s.insertIdentityPosts,
+	inserters, err := s.insertOrder()
+	if err != nil {
+		return err
+	}
+
+	return s.runInserters(ctx, inserters)
+}
+
+// runs the inserters on up to Cfg.workers pooled connections at once. keys and references between
+// seeded tables only exist after the transatory phase, so the COPYs don't depend on each other and
+// are handed out in insert order, a single worker inserts exactly like before. every inserter runs even
+// when others fail, the failures are returned together.
+func (s *Seeder) runInserters(ctx context.Context, inserters []SeedFunc) error {
+	workers := s.Cfg.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan SeedFunc)
+	failures := make(chan error, len(inserters))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(inserters); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ifn := range jobs {
+				if err := ifn(ctx); err != nil {
+					failures <- err
+				}
+			}
+		}()
 	}
 
 	for _, ifn := range inserters {
-		err := ifn()
-		if err != nil {
-			log.Fatal(err)
+		jobs <- ifn
+	}
+	close(jobs)
+
+	wg.Wait()
+	close(failures)
+
+	errs := []error{}
+	for err := range failures {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// generates the posts and everything hanging off them straight into the COPYs of their tables,
+// only the accounts, boards, articles and threads are kept in memory as the posts reference them.
+// the streamed tables are COPYed into at the same time as they're generated, regardless of the
+// worker limit, the rest are inserted afterwards.
+func (s *Seeder) seedStreaming(ctx context.Context) error {
+	fmt.Println("Generating data...")
+
+	s.seedAccounts()
+	s.seedBoards()
+	s.seedArticles()
+
+	fmt.Println("Streaming posts...")
+
+	wait := s.openStreams(ctx)
+	err := s.seedThreads(ctx)
+	if err == nil {
+		err = s.seedPosts(ctx)
+	}
+	s.streams.close()
+	s.seedDeletions()
+
+	errs := wait()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	if err := s.generated(); err != nil {
+		errs = append(errs, err)
+	}
+
+	fmt.Println("Batching queries...")
+
+	inserters, err := s.insertOrder(streamed_tables...)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	if err := s.runInserters(ctx, inserters); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// the inserter of every seeded table, keyed by the table it COPYs into
+func (s *Seeder) inserters() map[string]SeedFunc {
+	return map[string]SeedFunc{
+		"accounts":         s.insertAccounts,
+		"boards":           s.insertBoards,
+		"article_contents": s.insertArticleContent,
+		"articles":         s.insertArticles,
+		"threads":          s.insertThreads,
+		"post_contents":    s.insertPostContent,
+		"posts":            s.insertPosts,
+		"identities":       s.insertIdentities,
+		"identity_posts":   s.insertIdentityPosts,
+	}
+}
+
+// orders the inserters by the references between the seeded tables, so every table is filled
+// after the tables it points at. a seeded table without an inserter, or an inserter for a table
+// the schema doesn't seed, is an error rather than a table silently left empty. the inserters of
+// the excluded tables are left out.
+func (s *Seeder) insertOrder(exclude ...string) ([]SeedFunc, error) {
+	tables, err := seed_schema.Order()
+	if err != nil {
+		return nil, err
+	}
+
+	inserters := s.inserters()
+	ordered := []SeedFunc{}
+
+	excluded := map[string]bool{}
+	for _, name := range exclude {
+		excluded[name] = true
+	}
+
+	for _, t := range tables {
+		if !t.Seeded() {
+			continue
 		}
+		ifn, ok := inserters[t.Name]
+		if !ok {
+			return nil, fmt.Errorf("seeded table %s has no inserter", t.Name)
+		}
+		if !excluded[t.Name] {
+			ordered = append(ordered, ifn)
+		}
+		delete(inserters, t.Name)
+	}
+
+	for name := range inserters {
+		return nil, fmt.Errorf("inserter for %s which is not a seeded table", name)
 	}
+
+	return ordered, nil
 }
 
 /* ACCOUNT */
@@ -624,14 +1511,17 @@ type Account struct {
 	CreatedAt *time.Time
 	UpdatedAt *time.Time
 	DeletedAt *time.Time
+
+	// the latest thread or post of the account, and how many posts it made
+	active time.Time
+	posts  int
 }
 
-func newAccount(id int) *Account {
-	ts := time.Now()
+func newAccount(id int, created time.Time, updated time.Time) *Account {
 	return &Account{
 		ID:        id,
-		CreatedAt: &ts,
-		UpdatedAt: &ts,
+		CreatedAt: &created,
+		UpdatedAt: &updated,
 	}
 }
 
@@ -643,47 +1533,40 @@ func (a *Account) track(s *Seeder) {
 		s.Mods = append(s.Mods, a)
 	}
 	s.Accounts = append(s.Accounts, a)
-	ts := time.Now()
-	a.UpdatedAt = &ts
 }
 
 func (s *Seeder) seedAccounts() {
-	num := RandomBetween(s.Cfg.minAccountCount, s.Cfg.maxAccountCount)
+	num := Uniform{Min: s.Cfg.minAccountCount, Max: s.Cfg.maxAccountCount}.Sample(s.Rand)
 	var sum int = 0
 
-	for _, account := range default_accounts {
+	// the fixed accounts run the place from the start
+	for _, account := range s.Cfg.accounts {
 		sum++
-		a := newAccount(sum)
+		a := newAccount(sum, s.Cfg.since, s.Cfg.since)
 		a.Username = account[0]
 		a.Email = account[1]
 		a.Role = AccountRole(account[2])
-		a.Status = RandomFromChoice[AccountStatus](AccountStatusActive, AccountStatusInactive)
+		a.Status = RandomFromChoice[AccountStatus](s.Rand, AccountStatusActive, AccountStatusInactive)
 		a.track(s)
 	}
 
 	for i := 0; i < num; i++ {
 		sum++
-		a := newAccount(sum)
-		a.Role = RandomEnumAccountRole()
-		a.Status = RandomEnumAccountStatus()
-		a.Username = NewUsername()
-		a.Email = AddDomainSuffix(a.Username)
+		created := s.timeAfter(s.Cfg.since)
+		a := newAccount(sum, created, s.timeAfter(created))
+		a.Role = s.dists.accountRole.Pick(s.Rand).(AccountRole)
+		a.Status = s.dists.accountStatus.Pick(s.Rand).(AccountStatus)
+		a.Username = NewUsername(s.Rand)
+		a.Email = a.Username + "@" + s.dists.emailDomain.Pick(s.Rand)
 		a.track(s)
 	}
 }
 
-func (s *Seeder) insertAccounts() *SeedDBError {
-	tx, _ := s.Store.DB.Begin()
-	stmt, _ := tx.Prepare(pq.CopyIn("accounts", "id", "username", "email", "status_id", "role_id"))
-
-	for _, act := range s.Accounts {
-		_, err := stmt.Exec(act.ID, act.Username, act.Email, act.Status.ID(), act.Role.ID())
-		if err != nil {
-			return &SeedDBError{Model: "Account", Service: StatementExecError, Message: err.Error()}
-		}
-	}
-
-	return finalizeTransaction("Account", tx, stmt)
+func (s *Seeder) insertAccounts(ctx context.Context) *SeedDBError {
+	return s.copySlice(ctx, "Account", "accounts", len(s.Accounts), func(i int) []any {
+		act := s.Accounts[i]
+		return []any{act.ID, act.Username, act.Email, act.Status.ID(), act.Role.ID(), *act.CreatedAt, *act.UpdatedAt, act.DeletedAt}
+	})
 }
 
 /* BOARD */
@@ -697,51 +1580,43 @@ type Board struct {
 
 	PostCount int
 
-	ThreadIDMap   map[int]*Thread
-	ThreadWeights map[int]int
+	ThreadIDMap map[int]*Thread
+
+	// the threads of the board in the order they were opened
+	threads []*Thread
 
 	CreatedAt *time.Time
 	UpdatedAt *time.Time
 	DeletedAt *time.Time
 }
 
-func newBoard(id int) *Board {
-	ts := time.Now().UTC()
+func newBoard(id int, created time.Time) *Board {
 	return &Board{
-		ID:            id,
-		PostCount:     1,
-		ThreadIDMap:   map[int]*Thread{},
-		ThreadWeights: map[int]int{},
-		CreatedAt:     &ts,
-		UpdatedAt:     &ts,
+		ID:          id,
+		PostCount:   1,
+		ThreadIDMap: map[int]*Thread{},
+		CreatedAt:   &created,
+		UpdatedAt:   &created,
 	}
 }
 
 func (s *Seeder) seedBoards() {
-	for i, board := range default_boards {
-		b := newBoard(i + 1)
+	for i, board := range s.Cfg.boards {
+		b := newBoard(i+1, s.Cfg.since)
 		b.Title = board[0]
 		b.Short = board[1]
 		b.Desc = board[2]
-		s.BoardWeights[b.ID] = default_board_weight
 		s.BoardIDMap[b.ID] = b
 
 		s.Boards = append(s.Boards, b)
 	}
 }
 
-func (s *Seeder) insertBoards() *SeedDBError {
-	tx, _ := s.Store.DB.Begin()
-	stmt, _ := tx.Prepare(pq.CopyIn("boards", "id", "title", "short", "description", "post_count"))
-
-	for _, board := range s.Boards {
-		_, err := stmt.Exec(board.ID, board.Title, board.Short, board.Desc, board.PostCount)
-		if err != nil {
-			return &SeedDBError{Model: "Board", Service: StatementExecError, Message: err.Error()}
-		}
-	}
-
-	return finalizeTransaction("Board", tx, stmt)
+func (s *Seeder) insertBoards(ctx context.Context) *SeedDBError {
+	return s.copySlice(ctx, "Board", "boards", len(s.Boards), func(i int) []any {
+		board := s.Boards[i]
+		return []any{board.ID, board.Title, board.Short, board.Desc, board.PostCount, *board.CreatedAt, *board.UpdatedAt}
+	})
 }
 
 /* ARTICLE & ARTICLE CONTENT */
@@ -767,16 +1642,13 @@ type ArticleContent struct {
 }
 
 func newArticle(id int) *Article {
-	ts := time.Now().UTC()
 	return &Article{
-		ID:        id,
-		CreatedAt: &ts,
-		UpdatedAt: &ts,
+		ID: id,
 	}
 }
 
-func newArticleContent(id int) *ArticleContent {
-	lorem := NewLorem()
+func newArticleContent(id int, s *Seeder) *ArticleContent {
+	lorem := NewLorem(s.Rand, s.Cfg.lorem...)
 	ac := &ArticleContent{
 		ID:      id,
 		Content: lorem.Generate(),
@@ -785,22 +1657,26 @@ func newArticleContent(id int) *ArticleContent {
 }
 
 func (s *Seeder) seedArticles() {
-	num := RandomBetween(s.Cfg.minArticleCount, s.Cfg.maxArticleCount)
-	loremTitle := NewLorem(LoremPunctuation(false), LoremMaxSentenceLength(10))
+	num := Uniform{Min: s.Cfg.minArticleCount, Max: s.Cfg.maxArticleCount}.Sample(s.Rand)
+	loremTitle := NewLorem(s.Rand, LoremPunctuation(false), LoremMaxSentenceLength(10))
 
 	for i := 0; i < num; i++ {
-		ts := time.Now().UTC()
-
 		a := newArticle(i + 1)
-		ac := newArticleContent(i + 1)
+		ac := newArticleContent(i+1, s)
 
 		a.Title = loremTitle.GenerateSentence()
-		a.Author = RandomFromList[*Account](s.Admins)
-		a.Status = RandomEnumArticleStatus()
-		a.Slug = NewArticleSlug()
-
-		a.CreatedAt = &ts
-		a.UpdatedAt = &ts
+		a.Author = RandomFromList[*Account](s.Rand, s.Admins)
+		a.Status = s.dists.articleStatus.Pick(s.Rand).(ArticleStatus)
+		a.Slug = NewArticleSlug(s.Rand)
+
+		// written by an author who's already there, edited some time after
+		created := s.timeAfter(*a.Author.CreatedAt)
+		updated := s.timeAfter(created)
+		a.CreatedAt = &created
+		a.UpdatedAt = &updated
+		a.Author.seen(updated)
+		a.DeletedAt = s.softDelete("articles", a.Status, updated)
+		a.UpdatedAt = deletedUpdate(a.UpdatedAt, a.DeletedAt)
 
 		a.Content = ac
 
@@ -809,31 +1685,18 @@ func (s *Seeder) seedArticles() {
 	}
 }
 
-func (s *Seeder) insertArticleContent() *SeedDBError {
-	tx, _ := s.Store.DB.Begin()
-	stmt, _ := tx.Prepare(pq.CopyIn("article_contents", "id", "content"))
-
-	for _, ac := range s.ArticleContent {
-		_, err := stmt.Exec(ac.ID, ac.Content)
-		if err != nil {
-			return &SeedDBError{Model: "ArticleContent", Service: StatementExecError, Message: err.Error()}
-		}
-	}
-
-	return finalizeTransaction("ArticleContent", tx, stmt)
+func (s *Seeder) insertArticleContent(ctx context.Context) *SeedDBError {
+	return s.copySlice(ctx, "ArticleContent", "article_contents", len(s.ArticleContent), func(i int) []any {
+		ac := s.ArticleContent[i]
+		return []any{ac.ID, ac.Content}
+	})
 }
 
-func (s *Seeder) insertArticles() *SeedDBError {
-	tx, _ := s.Store.DB.Begin()
-	stmt, _ := tx.Prepare(pq.CopyIn("articles", "id", "title", "slug", "content_id", "status_id", "author_id"))
-
-	for _, a := range s.Articles {
-		_, err := stmt.Exec(a.ID, a.Title, a.Slug, a.Content.ID, a.Status.ID(), a.Author.ID)
-		if err != nil {
-			return &SeedDBError{Model: "Article", Service: StatementExecError, Message: err.Error()}
-		}
-	}
-	return finalizeTransaction("Article", tx, stmt)
+func (s *Seeder) insertArticles(ctx context.Context) *SeedDBError {
+	return s.copySlice(ctx, "Article", "articles", len(s.Articles), func(i int) []any {
+		a := s.Articles[i]
+		return []any{a.ID, a.Title, a.Slug, a.Content.ID, a.Status.ID(), a.Author.ID, *a.CreatedAt, *a.UpdatedAt, a.DeletedAt}
+	})
 }
 
 /* IDENTITY */
@@ -857,47 +1720,58 @@ type Identity struct {
 	DeletedAt *time.Time
 }
 
-var identity_id_counter int = 0
-
 // either resolves the identity or creates a new one. each thread should have exactly one identity
-// per account regardless of the number of posts the account has made in the thread.
-func resolveIdentity(account_id int, thread_id int, board_id int, s *Seeder) *Identity {
+// per account regardless of the number of posts the account has made in the thread, it's created
+// with the first of them.
+func resolveIdentity(account_id int, thread_id int, board_id int, at time.Time, s *Seeder) *Identity {
 	exist, ok := s.identityHeapIndex[thread_id][account_id]
 	if ok {
 		return exist
 	}
 
-	identity_id_counter++
-	ts := time.Now().UTC()
+	created := newIdentity(account_id, thread_id, board_id, at, s)
+	s.addIdentity(created)
+	return created
+}
+
+// creates an identity without recording it, so it can be adjusted before it's streamed
+func newIdentity(account_id int, thread_id int, board_id int, at time.Time, s *Seeder) *Identity {
+	s.ids.identity++
 	created := &Identity{
-		ID:        identity_id_counter,
+		ID:        s.ids.identity,
 		AccountID: account_id,
 		ThreadID:  thread_id,
 		BoardID:   board_id,
-		Style:     RandomEnumIdentityStyle(),
-		Status:    RandomEnumIdentityStatus(),
-		Role:      RandomEnumThreadRole(),
-		Name:      NewIdentitySlug(),
-		CreatedAt: &ts,
-		UpdatedAt: &ts,
-	}
-	s.identityHeapIndex[thread_id][account_id] = created
-	s.Identities = append(s.Identities, created)
+		Style:     RandomEnumIdentityStyle(s.Rand),
+		Status:    s.dists.identityStatus.Pick(s.Rand).(IdentityStatus),
+		Role:      s.dists.threadRole.Pick(s.Rand).(ThreadRole),
+		Name:      NewIdentitySlug(s.Rand),
+		CreatedAt: &at,
+		UpdatedAt: &at,
+	}
+	created.DeletedAt = s.softDelete("identities", created.Status, at)
+	created.UpdatedAt = deletedUpdate(created.UpdatedAt, created.DeletedAt)
 	return created
 }
 
-func (s *Seeder) insertIdentities() *SeedDBError {
-	tx, _ := s.Store.DB.Begin()
-	stmt, _ := tx.Prepare(pq.CopyIn("identities", "id", "thread_id", "account_id", "name", "style_id", "status_id", "role_id", "board_id"))
-
-	for _, id := range s.Identities {
-		_, err := stmt.Exec(id.ID, id.ThreadID, id.AccountID, id.Name, id.Style.ID(), id.Status.ID(), id.Role.ID(), id.BoardID)
-		if err != nil {
-			return &SeedDBError{Model: "Identity", Service: StatementExecError, Message: err.Error()}
-		}
+func (s *Seeder) addIdentity(i *Identity) {
+	s.identityHeapIndex[i.ThreadID][i.AccountID] = i
+	if s.streams != nil {
+		s.streams.identities <- i.row()
+		s.streamed["identities"]++
+		return
 	}
+	s.Identities = append(s.Identities, i)
+}
 
-	return finalizeTransaction("Identity", tx, stmt)
+func (i *Identity) row() []any {
+	return []any{i.ID, i.ThreadID, i.AccountID, i.Name, i.Style.ID(), i.Status.ID(), i.Role.ID(), i.BoardID, *i.CreatedAt, *i.UpdatedAt, i.DeletedAt}
+}
+
+func (s *Seeder) insertIdentities(ctx context.Context) *SeedDBError {
+	return s.copySlice(ctx, "Identity", "identities", len(s.Identities), func(i int) []any {
+		return s.Identities[i].row()
+	})
 }
 
 /* THREAD & THREAD CONTENTS */
@@ -915,65 +1789,64 @@ type Thread struct {
 	CreatedAt *time.Time
 	UpdatedAt *time.Time
 	DeletedAt *time.Time
+
+	// the account that opened the thread and the number of replies it gets
+	creator *Account
+	replies int
 }
 
-func newThread(id int, board_id int) *Thread {
-	ts := time.Now().UTC()
+func newThread(id int, board_id int, created time.Time, s *Seeder) *Thread {
 	return &Thread{
 		ID:        id,
-		Status:    RandomEnumThreadStatus(),
+		Status:    s.dists.threadStatus.Pick(s.Rand).(ThreadStatus),
 		BoardID:   board_id,
-		CreatedAt: &ts,
-		UpdatedAt: &ts,
+		CreatedAt: &created,
+		UpdatedAt: &created,
 	}
 }
 
-func (s *Seeder) seedThreads() {
-	loremTitle := NewLorem(LoremPunctuation(false), LoremMaxSentenceLength(10))
+// opens the threads of every board at random moments of the window, in order, so their ids follow
+// their creation time. their opening posts are made with the replies by seedPosts.
+func (s *Seeder) seedThreads(ctx context.Context) error {
+	loremTitle := NewLorem(s.Rand, LoremPunctuation(false), LoremMaxSentenceLength(10))
 	var sum int = 0
 
 	for _, board := range s.Boards {
-		num := RandomBetween[int](s.Cfg.minThreadPerBoard, s.Cfg.maxThreadPerBoard)
+		num := Uniform{Min: s.Cfg.minThreadPerBoard, Max: s.Cfg.maxThreadPerBoard}.Sample(s.Rand)
+
+		for _, created := range s.trafficTimesAfter(*board.CreatedAt, num) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 
-		for i := 0; i < num; i++ {
 			sum++
-			thread := newThread(sum, board.ID)
+			thread := newThread(sum, board.ID, created, s)
 			s.identityHeapIndex[thread.ID] = map[int]*Identity{}
 
 			thread.Title = loremTitle.GenerateSentence()
-			thread.Slug = NewThreadSlug()
-
-			creatorAccount := RandomFromList[*Account](s.Accounts)
-			creator := resolveIdentity(creatorAccount.ID, thread.ID, board.ID, s)
-			creator.Role = ThreadRoleCreator
+			thread.Slug = NewThreadSlug(s.Rand)
 
-			postContent := newPostContent()
-			post := newPost(thread.ID, board.ID, postContent.ID, creatorAccount.ID, s)
-
-			newIdentityPost(creator.ID, board.ID, post.ID, s)
+			creator, err := s.activeAccount()
+			if err != nil {
+				return err
+			}
+			thread.creator = creator
+			thread.creator.seen(created)
 
-			s.PostContent = append(s.PostContent, postContent)
-			s.Posts = append(s.Posts, post)
 			s.Threads = append(s.Threads, thread)
 
+			board.threads = append(board.threads, thread)
 			board.ThreadIDMap[thread.ID] = thread
-			board.ThreadWeights[thread.ID] = default_thread_weight
 		}
 	}
+	return nil
 }
 
-func (s *Seeder) insertThreads() *SeedDBError {
-	tx, _ := s.Store.DB.Begin()
-	stmt, _ := tx.Prepare(pq.CopyIn("threads", "id", "board_id", "title", "slug", "status_id"))
-
-	for _, t := range s.Threads {
-		_, err := stmt.Exec(t.ID, t.BoardID, t.Title, t.Slug, t.Status.ID())
-		if err != nil {
-			return &SeedDBError{Model: "Thread", Service: StatementExecError, Message: err.Error()}
-		}
-	}
-
-	return finalizeTransaction("Thread", tx, stmt)
+func (s *Seeder) insertThreads(ctx context.Context) *SeedDBError {
+	return s.copySlice(ctx, "Thread", "threads", len(s.Threads), func(i int) []any {
+		t := s.Threads[i]
+		return []any{t.ID, t.BoardID, t.Title, t.Slug, t.Status.ID(), *t.CreatedAt, *t.UpdatedAt, t.DeletedAt}
+	})
 }
 
 /* POST & POST CONTENT */
@@ -988,86 +1861,158 @@ type Post struct {
 	AccountID int
 
 	PostNumber int
-}
 
-var post_id_counter int = 0
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+	DeletedAt *time.Time
+}
 
-func newPost(thread_id, board_id, content_id, account_id int, s *Seeder) *Post {
-	post_id_counter++
+func newPost(thread_id, board_id, content_id, account_id int, at time.Time, s *Seeder) *Post {
+	s.ids.post++
 	s.BoardIDMap[board_id].PostCount++
+	deleted := s.softDelete("posts", nil, at)
 	return &Post{
-		ID:         post_id_counter,
+		ID:         s.ids.post,
 		PostNumber: s.BoardIDMap[board_id].PostCount,
 		ThreadID:   thread_id,
 		BoardID:    board_id,
 		ContentID:  content_id,
 		AccountID:  account_id,
+		CreatedAt:  &at,
+		UpdatedAt:  deletedUpdate(&at, deleted),
+		DeletedAt:  deleted,
 	}
 }
 
-func (s *Seeder) GetWeightedBoard(adjustment int) *Board {
-	id := RandomWeightedFromMap[int](s.BoardWeights)
-	board, ok := s.BoardIDMap[id]
-	if !ok {
-		log.Fatal("reference exception: out of bounds board index", id)
+// every board draws a number of posts per thread, and its threads times that many replies are
+// spread over all the threads of all the boards by their popularity: a reply goes to a board by the
+// popularity of the boards and to one of its threads by the popularity of its threads.
+//
+// the picks only count the replies of every thread. the posts are made board by board afterwards,
+// in the order they happen, so post numbers follow time and only the timeline of a single board
+// is held in memory.
+func (s *Seeder) seedPosts(ctx context.Context) error {
+	boards := s.Cfg.popularity["boards"].distribution(s.Rand, len(s.Boards))
+	threads := make([]Distribution, len(s.Boards))
+	replies := 0
+
+	for i, board := range s.Boards {
+		threads[i] = s.Cfg.popularity["threads"].distribution(s.Rand, len(board.threads))
+		replies += Uniform{Min: s.Cfg.minPostPerThread, Max: s.Cfg.maxPostPerThread}.Sample(s.Rand) * len(board.threads)
 	}
-	s.BoardWeights[board.ID] = s.BoardWeights[board.ID] - adjustment
-	return board
-}
 
-func (s *Seeder) GetWeightedThread(board *Board, adjustment int) *Thread {
-	id := RandomWeightedFromMap[int](board.ThreadWeights)
-	thread, ok := board.ThreadIDMap[id]
-	if !ok {
-		log.Fatal("reference exception: out of bounds thread index", id)
-	}
-	board.ThreadWeights[thread.ID] = board.ThreadWeights[thread.ID] - adjustment
-	return thread
-}
+	for n := 0; n < replies; n++ {
+		if n%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
 
-// each board --> a random number of threads are chosen from a random board. although we
-// are iterating over each board here, we choose a random thread from a random board influcned
-// by a dynamically shifting weighted distribution. it makes the data feel more natural.
-func (s *Seeder) seedPosts() {
-	var sum int = 0
-	for i := 0; i < len(s.Boards); i++ {
-		num := RandomBetween[int](s.Cfg.minPostPerThread, s.Cfg.maxPostPerThread)
-		randomBoard := s.GetWeightedBoard(0)
+		// boards without threads are drawn again, there's one with threads or nothing to reply to
+		i := -1
+		for i < 0 || len(s.Boards[i].threads) == 0 {
+			var err error
+			if i, err = pick(s.Rand, "board", len(s.Boards), boards); err != nil {
+				return err
+			}
+		}
 
-		for j := 0; j < len(randomBoard.ThreadIDMap); j++ {
-			for k := 0; k < num; k++ {
-				sum++
+		t, err := pick(s.Rand, "thread", len(s.Boards[i].threads), threads[i])
+		if err != nil {
+			return err
+		}
+		s.Boards[i].threads[t].replies++
+	}
 
-				board := s.GetWeightedBoard(k + j)
-				thread := s.GetWeightedThread(board, k)
+	for _, board := range s.Boards {
+		if err := s.seedBoardPosts(ctx, board); err != nil {
+			return err
+		}
 
-				postContent := newPostContent()
-				account := RandomFromList[*Account](s.Accounts)
+		// the board is done, nothing resolves the identities of its threads anymore
+		for _, thread := range board.threads {
+			delete(s.identityHeapIndex, thread.ID)
+		}
+	}
+	return nil
+}
 
-				identity := resolveIdentity(account.ID, thread.ID, board.ID, s)
-				post := newPost(thread.ID, board.ID, postContent.ID, account.ID, s)
+// opens the threads of a board and posts their replies, and the bursts of the ones that go viral,
+// in the order they happen
+func (s *Seeder) seedBoardPosts(ctx context.Context, board *Board) error {
+	events := []boardEvent{}
+	for _, thread := range board.threads {
+		events = append(events, boardEvent{thread: thread, at: *thread.CreatedAt, opening: true})
+		for i := 0; i < thread.replies; i++ {
+			events = append(events, boardEvent{thread: thread, at: s.trafficAfter(*thread.CreatedAt)})
+		}
+
+		burst := s.burst(thread)
+		for _, at := range burst {
+			events = append(events, boardEvent{thread: thread, at: at})
+		}
+		if len(burst) > 0 {
+			s.bursts++
+		}
+	}
+	sortBoardEvents(events)
 
-				newIdentityPost(identity.ID, board.ID, post.ID, s)
+	for i, event := range events {
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
 
-				s.PostContent = append(s.PostContent, postContent)
-				s.Posts = append(s.Posts, post)
+		thread := event.thread
+		postContent := newPostContent(s)
+
+		var account *Account
+		var identity *Identity
+		if event.opening {
+			account = thread.creator
+			identity = newIdentity(account.ID, thread.ID, board.ID, event.at, s)
+			identity.Role = ThreadRoleCreator
+			s.addIdentity(identity)
+		} else {
+			var err error
+			if account, err = s.activeAccount(); err != nil {
+				return err
 			}
+			identity = resolveIdentity(account.ID, thread.ID, board.ID, event.at, s)
 		}
+
+		post := newPost(thread.ID, board.ID, postContent.ID, account.ID, event.at, s)
+		newIdentityPost(identity.ID, board.ID, post.ID, s)
+
+		s.addPostContent(postContent)
+		s.addPost(post)
+
+		account.seen(event.at)
+		account.posts++
+		thread.touch(event.at)
+		board.touch(event.at)
 	}
+	return nil
 }
 
-func (s *Seeder) insertPosts() *SeedDBError {
-	tx, _ := s.Store.DB.Begin()
-	stmt, _ := tx.Prepare(pq.CopyIn("posts", "id", "thread_id", "board_id", "content_id", "account_id", "post_number"))
-
-	for _, p := range s.Posts {
-		_, err := stmt.Exec(p.ID, p.ThreadID, p.BoardID, p.ContentID, p.AccountID, p.PostNumber)
-		if err != nil {
-			return &SeedDBError{Model: "Post", Service: StatementExecError, Message: err.Error()}
-		}
+func (s *Seeder) addPost(p *Post) {
+	if s.streams != nil {
+		s.streams.posts <- p.row()
+		s.streamed["posts"]++
+		return
 	}
+	s.Posts = append(s.Posts, p)
+}
 
-	return finalizeTransaction("Post", tx, stmt)
+func (p *Post) row() []any {
+	return []any{p.ID, p.ThreadID, p.BoardID, p.ContentID, p.AccountID, p.PostNumber, *p.CreatedAt, *p.UpdatedAt, p.DeletedAt}
+}
+
+func (s *Seeder) insertPosts(ctx context.Context) *SeedDBError {
+	return s.copySlice(ctx, "Post", "posts", len(s.Posts), func(i int) []any {
+		return s.Posts[i].row()
+	})
 }
 
 type PostContent struct {
@@ -1075,29 +2020,32 @@ type PostContent struct {
 	Content string
 }
 
-var post_content_id_counter int = 0
-
-func newPostContent() *PostContent {
-	post_content_id_counter++
-	lorem := NewLorem()
+func newPostContent(s *Seeder) *PostContent {
+	s.ids.postContent++
+	lorem := NewLorem(s.Rand, s.Cfg.lorem...)
 	return &PostContent{
-		ID:      post_content_id_counter,
+		ID:      s.ids.postContent,
 		Content: lorem.Generate(),
 	}
 }
 
-func (s *Seeder) insertPostContent() *SeedDBError {
-	tx, _ := s.Store.DB.Begin()
-	stmt, _ := tx.Prepare(pq.CopyIn("post_contents", "id", "content"))
-
-	for _, pc := range s.PostContent {
-		_, err := stmt.Exec(pc.ID, pc.Content)
-		if err != nil {
-			return &SeedDBError{Model: "PostContent", Service: StatementExecError, Message: err.Error()}
-		}
+func (s *Seeder) addPostContent(pc *PostContent) {
+	if s.streams != nil {
+		s.streams.postContents <- pc.row()
+		s.streamed["post_contents"]++
+		return
 	}
+	s.PostContent = append(s.PostContent, pc)
+}
 
-	return finalizeTransaction("PostContent", tx, stmt)
+func (pc *PostContent) row() []any {
+	return []any{pc.ID, pc.Content}
+}
+
+func (s *Seeder) insertPostContent(ctx context.Context) *SeedDBError {
+	return s.copySlice(ctx, "PostContent", "post_contents", len(s.PostContent), func(i int) []any {
+		return s.PostContent[i].row()
+	})
 }
 
 /* IDENTITY POSTS */
@@ -1110,29 +2058,247 @@ type IdentityPost struct {
 	PostID     int
 }
 
-var identity_post_id_counter int = 0
-
 func newIdentityPost(identity_id, board_id, post_id int, s *Seeder) {
-	identity_post_id_counter++
+	s.ids.identityPost++
 	created := &IdentityPost{
-		ID:         identity_post_id_counter,
+		ID:         s.ids.identityPost,
 		IdentityID: identity_id,
 		BoardID:    board_id,
 		PostID:     post_id,
 	}
+	if s.streams != nil {
+		s.streams.identityPosts <- created.row()
+		s.streamed["identity_posts"]++
+		return
+	}
 	s.IdentityPosts = append(s.IdentityPosts, created)
 }
 
-func (s *Seeder) insertIdentityPosts() *SeedDBError {
-	tx, _ := s.Store.DB.Begin()
-	stmt, _ := tx.Prepare(pq.CopyIn("identity_posts", "id", "identity_id", "board_id", "post_id"))
+func (idp *IdentityPost) row() []any {
+	return []any{idp.ID, idp.IdentityID, idp.BoardID, idp.PostID}
+}
+
+func (s *Seeder) insertIdentityPosts(ctx context.Context) *SeedDBError {
+	return s.copySlice(ctx, "IdentityPost", "identity_posts", len(s.IdentityPosts), func(i int) []any {
+		return s.IdentityPosts[i].row()
+	})
+}
+
+/* STREAMING */
+/*************/
+
+// the tables whose rows are streamed instead of collected
+var streamed_tables = []string{"post_contents", "posts", "identities", "identity_posts"}
+
+// channels carrying the generated rows of the streamed tables to their COPY
+type rowStreams struct {
+	postContents  chan []any
+	posts         chan []any
+	identities    chan []any
+	identityPosts chan []any
+}
+
+func (rs *rowStreams) close() {
+	close(rs.postContents)
+	close(rs.posts)
+	close(rs.identities)
+	close(rs.identityPosts)
+}
+
+// opens a COPY for every streamed table, each on its own connection, and points the generators at
+// them. the returned function waits for every COPY to finish once the streams are closed and
+// returns their failures.
+func (s *Seeder) openStreams(ctx context.Context) func() []error {
+	s.streams = &rowStreams{
+		postContents:  make(chan []any, stream_buffer),
+		posts:         make(chan []any, stream_buffer),
+		identities:    make(chan []any, stream_buffer),
+		identityPosts: make(chan []any, stream_buffer),
+	}
+
+	copies := []struct {
+		model string
+		table string
+		rows  <-chan []any
+	}{
+		{"PostContent", "post_contents", s.streams.postContents},
+		{"Post", "posts", s.streams.posts},
+		{"Identity", "identities", s.streams.identities},
+		{"IdentityPost", "identity_posts", s.streams.identityPosts},
+	}
+
+	failures := make(chan error, len(copies))
+	var wg sync.WaitGroup
 
-	for _, idp := range s.IdentityPosts {
-		_, err := stmt.Exec(idp.ID, idp.IdentityID, idp.BoardID, idp.PostID)
+	for _, c := range copies {
+		wg.Add(1)
+		go func(model, table string, rows <-chan []any) {
+			defer wg.Done()
+			if err := s.copyStream(ctx, model, table, rows); err != nil {
+				failures <- err
+			}
+		}(c.model, c.table, c.rows)
+	}
+
+	return func() []error {
+		wg.Wait()
+		close(failures)
+
+		errs := []error{}
+		for err := range failures {
+			errs = append(errs, err)
+		}
+		return errs
+	}
+}
+
+// COPYs every row received until the stream is closed. after a failure the rest of the stream is
+// drained so the generators never block on it.
+func (s *Seeder) copyStream(ctx context.Context, model string, table string, rows <-chan []any) *SeedDBError {
+	err := s.copyChunks(ctx, model, table, -1, func(int) ([]any, bool) {
+		row, ok := <-rows
+		return row, ok
+	})
+	if err != nil {
+		for range rows {
+		}
+	}
+	return err
+}
+
+/* CHUNKED COPY */
+/****************/
+
+// COPYs the n rows of a slice, row returns the values of the i-th one
+func (s *Seeder) copySlice(ctx context.Context, model string, table string, n int, row func(i int) []any) *SeedDBError {
+	return s.copyChunks(ctx, model, table, n, func(i int) ([]any, bool) {
+		if i >= n {
+			return nil, false
+		}
+		return row(i), true
+	})
+}
+
+// COPYs the rows returned by next into the table until it runs out, committing every
+// Cfg.batchSize rows in a transaction of their own. rows an earlier run already committed are
+// skipped. total is only used for the progress report, -1 when it isn't known up front. the
+// context is checked before every row, cancelling it rolls back the chunk being copied.
+func (s *Seeder) copyChunks(ctx context.Context, model string, table string, total int, next func(i int) ([]any, bool)) *SeedDBError {
+	skip, done := s.committed(table)
+	if done && total >= 0 {
+		return nil
+	}
+
+	i := 0
+	for ; i < skip || done; i++ {
+		if _, ok := next(i); !ok {
+			return nil
+		}
+	}
+
+	for {
+		row, ok := next(i)
+		if !ok {
+			return s.complete(model, table)
+		}
+
+		start := i
+
+		if err := ctx.Err(); err != nil {
+			return newSeedDBError(model, CancellationError, i, err)
+		}
+
+		tx, err := s.Store.DB.BeginTx(ctx, nil)
 		if err != nil {
-			return &SeedDBError{Model: "IdentityPost", Service: StatementExecError, Message: err.Error()}
+			return newSeedDBError(model, TransactionBeginError, -1, err)
+		}
+
+		stmt, err := tx.Prepare(copyIn(table))
+		if err != nil {
+			return rollback(tx, newSeedDBError(model, StatementPrepareError, -1, err))
+		}
+
+		for chunk := 0; ok; chunk++ {
+			if err := ctx.Err(); err != nil {
+				stmt.Close()
+				return rollback(tx, newSeedDBError(model, CancellationError, i, err))
+			}
+
+			_, err := stmt.Exec(row...)
+			if err != nil {
+				stmt.Close()
+				if ctx.Err() != nil {
+					return rollback(tx, newSeedDBError(model, CancellationError, i, ctx.Err()))
+				}
+				return rollback(tx, newSeedDBError(model, StatementExecError, copyErrorRow(err, start, i), err))
+			}
+			i++
+
+			if s.Cfg.batchSize > 0 && chunk+1 == s.Cfg.batchSize {
+				break
+			}
+			row, ok = next(i)
+		}
+
+		if err := finalizeTransaction(model, tx, stmt, start); err != nil {
+			return err
+		}
+		if err := s.commit(table, i, total); err != nil {
+			return newSeedDBError(model, CheckpointWriteError, -1, err)
+		}
+
+		if !ok {
+			return s.complete(model, table)
+		}
+	}
+}
+
+// the number of rows of the table committed so far, and whether its inserter already finished
+func (s *Seeder) committed(table string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Committed[table], s.completed[table]
+}
+
+// records that the first done rows of the table are committed and reports the progress
+func (s *Seeder) commit(table string, done int, total int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Committed[table] = done
+	if s.Cfg.progress != nil {
+		s.Cfg.progress(table, done, total)
+	}
+	return s.saveCheckpoint()
+}
+
+// records that every row of the table is committed
+func (s *Seeder) complete(model string, table string) *SeedDBError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completed[table] = true
+	if err := s.saveCheckpoint(); err != nil {
+		return newSeedDBError(model, CheckpointWriteError, -1, err)
+	}
+	return nil
+}
+
+// reads what an interrupted run committed. a chunk is committed before the checkpoint is written,
+// an interrupt in between leaves the checkpoint a chunk behind, so the row counts of the seeded
+// tables are what counts and the checkpoint only adds which tables were completed. chunks are
+// committed whole and in order, the row count of a table is exactly where its COPY has to continue.
+func (s *Seeder) loadCommitted() error {
+	if cp := s.Cfg.resumed; cp != nil {
+		for _, table := range cp.Completed {
+			s.completed[table] = true
 		}
 	}
 
-	return finalizeTransaction("IdentityPost", tx, stmt)
+	for _, t := range seed_schema.SeededTables() {
+		count, err := s.Store.RowCount(t.Name)
+		if err != nil {
+			return err
+		}
+		s.Committed[t.Name] = count
+	}
+	return nil
 }
@@ -0,0 +1,791 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dd-web/pgsvk-seeder/pkg/database"
+	"github.com/lib/pq"
+)
+
+/* SCHEMA SEEDER CONFIG / DEFAULTS */
+/***********************************/
+
+var (
+	schema_seeder_rows      = 100
+	schema_seeder_null_rate = 20 // percent chance a nullable column is left NULL
+	schema_seeder_retries   = 50 // attempts at generating a row that satisfies the unique constraints
+
+	// tables never seeded when introspecting, the migration bookkeeping
+	schema_seeder_excluded = []string{"schema_migrations"}
+)
+
+type SchemaSeederConfigFunc func(*SchemaSeederConfig) *SchemaSeederConfig
+
+type SchemaSeederConfig struct {
+	rows      int
+	tableRows map[string]int
+	nullRate  int
+	seed      int64
+
+	// every generated timestamp falls between these, the default window of the seeder
+	since time.Time
+	until time.Time
+}
+
+func defaultSchemaSeederConfig() *SchemaSeederConfig {
+	since, until := DefaultTimeWindow()
+	return &SchemaSeederConfig{
+		rows:      schema_seeder_rows,
+		tableRows: map[string]int{},
+		nullRate:  schema_seeder_null_rate,
+		seed:      time.Now().UnixNano(),
+		since:     since,
+		until:     until,
+	}
+}
+
+// number of rows generated for every table without a count of its own
+func SchemaCfgSetRows(i int) SchemaSeederConfigFunc {
+	return func(c *SchemaSeederConfig) *SchemaSeederConfig {
+		c.rows = i
+		return c
+	}
+}
+
+// number of rows generated for the given table
+func SchemaCfgSetTableRows(table string, i int) SchemaSeederConfigFunc {
+	return func(c *SchemaSeederConfig) *SchemaSeederConfig {
+		c.tableRows[table] = i
+		return c
+	}
+}
+
+// percent chance a nullable column is left NULL
+func SchemaCfgSetNullRate(i int) SchemaSeederConfigFunc {
+	return func(c *SchemaSeederConfig) *SchemaSeederConfig {
+		c.nullRate = i
+		return c
+	}
+}
+
+func SchemaCfgSetSeed(i int64) SchemaSeederConfigFunc {
+	return func(c *SchemaSeederConfig) *SchemaSeederConfig {
+		c.seed = i
+		return c
+	}
+}
+
+// sets the window generated timestamps fall in, the same seed only reproduces the same data with
+// the same window
+func SchemaCfgSetTimeWindow(since time.Time, until time.Time) SchemaSeederConfigFunc {
+	return func(c *SchemaSeederConfig) *SchemaSeederConfig {
+		c.since = since.UTC()
+		c.until = until.UTC()
+		return c
+	}
+}
+
+/* SCHEMA SEEDER */
+/*****************/
+
+// SchemaSeeder fills an arbitrary schema with referentially valid data. tables are filled in
+// dependency order, foreign keys are picked from the keys already in the referenced table, and
+// values are generated from the column types while keeping NOT NULL, UNIQUE and simple CHECK
+// constraints. tables that already hold rows are left alone but can still be referenced.
+type SchemaSeeder struct {
+	Store  *Store
+	Schema *database.Schema
+	Cfg    *SchemaSeederConfig
+	Rand   *rand.Rand
+
+	Results  []SchemaSeedResult
+	Warnings []string
+
+	lorem *Lorem
+
+	// table.column -> values available for references
+	keys map[string][]any
+}
+
+type SchemaSeedResult struct {
+	Table   string
+	Rows    int
+	Skipped string
+}
+
+func NewSchemaSeeder(s *Store, schema *database.Schema, cfg ...SchemaSeederConfigFunc) *SchemaSeeder {
+	config := defaultSchemaSeederConfig()
+	for _, fn := range cfg {
+		config = fn(config)
+	}
+
+	r := rand.New(rand.NewSource(config.seed))
+
+	return &SchemaSeeder{
+		Store:  s,
+		Schema: schema,
+		Cfg:    config,
+		Rand:   r,
+		lorem:  NewLorem(r, LoremPunctuation(false), LoremCapitalizeFirst(false), LoremMinSentenceLength(1), LoremMaxSentenceLength(6)),
+		keys:   map[string][]any{},
+	}
+}
+
+// IntrospectSeeder reads the given postgres schema from the database and creates a seeder for it
+func IntrospectSeeder(s *Store, schema string, cfg ...SchemaSeederConfigFunc) (*SchemaSeeder, error) {
+	described, err := database.Introspect(s.DB, schema, schema_seeder_excluded...)
+	if err != nil {
+		return nil, err
+	}
+
+	// a cycle is broken at a nullable reference, set once both tables have rows
+	if err := described.DeferCycles(); err != nil {
+		return nil, err
+	}
+
+	if err := described.Validate(); err != nil {
+		return nil, err
+	}
+
+	seeder := NewSchemaSeeder(s, described, cfg...)
+
+	// the tables introspection couldn't describe are reported with the results
+	skipped := make([]string, 0, len(described.Skipped))
+	for name := range described.Skipped {
+		skipped = append(skipped, name)
+	}
+	sort.Strings(skipped)
+	for _, name := range skipped {
+		seeder.Results = append(seeder.Results, SchemaSeedResult{Table: name, Skipped: described.Skipped[name]})
+	}
+
+	return seeder, nil
+}
+
+// Plan returns the tables in the order they're filled in
+func (s *SchemaSeeder) Plan() ([]*database.Table, error) {
+	return s.Schema.Order()
+}
+
+// Seed fills every empty table of the schema in dependency order, until the context is cancelled
+func (s *SchemaSeeder) Seed(ctx context.Context) error {
+	order, err := s.Plan()
+	if err != nil {
+		return err
+	}
+
+	seeded := []*database.Table{}
+	for _, t := range order {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var exists bool
+		err := s.Store.DB.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", pq.QuoteIdentifier(t.Name))).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			s.Results = append(s.Results, SchemaSeedResult{Table: t.Name, Skipped: "already has rows"})
+			continue
+		}
+
+		n, err := s.seedTable(ctx, t)
+		if err != nil {
+			return fmt.Errorf("seeding %s: %w", t.Name, err)
+		}
+		s.Results = append(s.Results, SchemaSeedResult{Table: t.Name, Rows: n})
+		if n > 0 {
+			seeded = append(seeded, t)
+		}
+	}
+
+	for _, t := range seeded {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.fillDeferred(ctx, t); err != nil {
+			return fmt.Errorf("seeding %s: %w", t.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SchemaSeeder) PrintResults() {
+	fmt.Print(UnderlinePrint("Results"))
+	fmt.Printf("  - Seed %v\n", s.Cfg.seed)
+	for _, res := range s.Results {
+		if res.Skipped != "" {
+			fmt.Printf("  - %v skipped, %v\n", res.Table, res.Skipped)
+			continue
+		}
+		fmt.Printf("  - %v %v\n", res.Rows, res.Table)
+	}
+	for _, warning := range s.Warnings {
+		fmt.Printf("  ! %v\n", warning)
+	}
+	fmt.Printf("-------------------------\n")
+}
+
+// a single column comparison from a CHECK constraint, the right side is a column or a number
+type columnCheck struct {
+	left   string
+	op     string
+	right  string
+	number *float64
+}
+
+var (
+	check_cast_pattern       = regexp.MustCompile(`::[a-z ]+(\[\])?`)
+	check_comparison_pattern = regexp.MustCompile(`^(\w+) (>=|<=|>|<|<>|=) (\S+)$`)
+)
+
+// parses the check constraints the generator knows how to satisfy, anything else is returned as
+// a warning
+func parseChecks(t *database.Table) ([]columnCheck, []string) {
+	checks := []columnCheck{}
+	warnings := []string{}
+
+	exprs := append([]string{}, t.Checks...)
+	for _, c := range t.Columns {
+		if c.Check != "" {
+			exprs = append(exprs, c.Check)
+		}
+	}
+
+	for _, expr := range exprs {
+		clean := check_cast_pattern.ReplaceAllString(expr, "")
+		clean = strings.NewReplacer("(", "", ")", "").Replace(clean)
+		clean = strings.Join(strings.Fields(clean), " ")
+
+		m := check_comparison_pattern.FindStringSubmatch(clean)
+		if m == nil || t.Column(m[1]) == nil {
+			warnings = append(warnings, fmt.Sprintf("%s: check %s is not enforced by the generator", t.Name, expr))
+			continue
+		}
+
+		check := columnCheck{left: m[1], op: m[2]}
+		if t.Column(m[3]) != nil {
+			check.right = m[3]
+		} else if f, err := strconv.ParseFloat(strings.Trim(m[3], "'"), 64); err == nil {
+			check.number = &f
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s: check %s is not enforced by the generator", t.Name, expr))
+			continue
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, warnings
+}
+
+// the columns of a table the seeder writes, everything the database can't fill itself. columns of
+// types the generator doesn't know are left to their default or NULL, without either it's an error.
+func writableColumns(t *database.Table) ([]*database.Column, error) {
+	cols := []*database.Column{}
+	for _, c := range t.Columns {
+		if c.Generated || strings.HasPrefix(c.Default, "nextval(") {
+			continue
+		}
+		if !supportedType(c) {
+			if c.Default != "" || !c.NotNull {
+				continue
+			}
+			return nil, fmt.Errorf("can't generate values of type %s for %s.%s", c.Type, t.Name, c.Name)
+		}
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+func (s *SchemaSeeder) seedTable(ctx context.Context, t *database.Table) (int, error) {
+	cols, err := writableColumns(t)
+	if err != nil {
+		return 0, err
+	}
+	if len(cols) == 0 {
+		return 0, nil
+	}
+
+	refs := map[string]*database.Reference{}
+	for _, ref := range t.References {
+		refs[ref.Column] = ref
+	}
+
+	checks, warnings := parseChecks(t)
+	s.Warnings = append(s.Warnings, warnings...)
+
+	uniques := [][]string{}
+	if t.PrimaryKey != "" {
+		uniques = append(uniques, []string{t.PrimaryKey})
+	}
+	for _, c := range t.Columns {
+		if c.Unique {
+			uniques = append(uniques, []string{c.Name})
+		}
+	}
+	uniques = append(uniques, t.Unique...)
+	seen := make([]map[string]bool, len(uniques))
+	for i := range seen {
+		seen[i] = map[string]bool{}
+	}
+
+	count, ok := s.Cfg.tableRows[t.Name]
+	if !ok {
+		count = s.Cfg.rows
+	}
+
+	// unique references hand out every referenced key once, in random order
+	cursors := map[string]int{}
+
+	rows := [][]any{}
+	for i := 0; i < count; i++ {
+		row, ok, err := s.row(t, cols, refs, checks, uniques, seen, cursors, i)
+		if err != nil {
+			return 0, err
+		}
+
+		// a non nullable unique reference ran out of keys, the table can't take more rows
+		if !ok {
+			s.Warnings = append(s.Warnings, fmt.Sprintf("%s: stopped after %d rows, no keys left to reference", t.Name, len(rows)))
+			break
+		}
+
+		values := make([]any, len(cols))
+		for j, c := range cols {
+			values[j] = row[c.Name]
+		}
+		rows = append(rows, values)
+	}
+
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+	}
+
+	if err := s.copyRows(ctx, t.Name, names, rows); err != nil {
+		return 0, err
+	}
+
+	return len(rows), nil
+}
+
+// generates row i of the table, retrying until it satisfies the unique constraints. returns false
+// when a unique reference ran out of keys.
+func (s *SchemaSeeder) row(t *database.Table, cols []*database.Column, refs map[string]*database.Reference, checks []columnCheck, uniques [][]string, seen []map[string]bool, cursors map[string]int, i int) (map[string]any, bool, error) {
+	for attempt := 0; attempt < schema_seeder_retries; attempt++ {
+		row := map[string]any{}
+
+		for _, c := range cols {
+			ref, isRef := refs[c.Name]
+			switch {
+			case isRef && ref.Deferred:
+				// set by fillDeferred once the referenced table has rows
+				row[c.Name] = nil
+			case isRef:
+				value, ok, err := s.reference(t, c, ref, cursors)
+				if err != nil || !ok {
+					return nil, ok, err
+				}
+				row[c.Name] = value
+			case c.Name == t.PrimaryKey && isInteger(c):
+				row[c.Name] = i + 1
+			default:
+				row[c.Name] = s.value(c, i)
+			}
+		}
+
+		applyChecks(row, checks)
+
+		if unique(row, uniques, seen) {
+			return row, true, nil
+		}
+	}
+
+	return nil, false, fmt.Errorf("could not generate a row satisfying the unique constraints after %d attempts", schema_seeder_retries)
+}
+
+// picks a key of the referenced table. returns false when a unique reference ran out of keys.
+func (s *SchemaSeeder) reference(t *database.Table, c *database.Column, ref *database.Reference, cursors map[string]int) (any, bool, error) {
+	nullable := !c.NotNull
+
+	if ref.Schema == "" && ref.Table == t.Name {
+		if nullable {
+			return nil, true, nil
+		}
+		return nil, false, fmt.Errorf("non nullable self reference %s.%s is not supported", t.Name, c.Name)
+	}
+
+	if nullable && s.Rand.Intn(100) < s.Cfg.nullRate {
+		return nil, true, nil
+	}
+
+	keys, err := s.keyPool(ref)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(keys) == 0 {
+		if nullable {
+			return nil, true, nil
+		}
+		return nil, false, fmt.Errorf("%s.%s references %s which has no rows", t.Name, c.Name, ref.Table)
+	}
+
+	if !c.Unique {
+		return keys[s.Rand.Intn(len(keys))], true, nil
+	}
+
+	cursor := cursors[c.Name]
+	if cursor >= len(keys) {
+		if nullable {
+			return nil, true, nil
+		}
+		return nil, false, nil
+	}
+	cursors[c.Name] = cursor + 1
+	return keys[cursor], true, nil
+}
+
+// loads the values of the referenced column, shuffled so unique references can walk through them
+func (s *SchemaSeeder) keyPool(ref *database.Reference) ([]any, error) {
+	column := ref.RefColumn
+	if column == "" {
+		column = "id"
+	}
+
+	table := pq.QuoteIdentifier(ref.Table)
+	if ref.Schema != "" {
+		table = pq.QuoteIdentifier(ref.Schema) + "." + table
+	}
+
+	key := table + "." + column
+	if keys, ok := s.keys[key]; ok {
+		return keys, nil
+	}
+
+	rows, err := s.Store.DB.Query(fmt.Sprintf("SELECT DISTINCT %s FROM %s WHERE %s IS NOT NULL ORDER BY 1",
+		pq.QuoteIdentifier(column), table, pq.QuoteIdentifier(column)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []any{}
+	for rows.Next() {
+		var value any
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		keys = append(keys, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	s.Rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	s.keys[key] = keys
+	return keys, nil
+}
+
+// records the unique keys of the row, returns false if any of them was already taken
+func unique(row map[string]any, uniques [][]string, seen []map[string]bool) bool {
+	keys := make([]string, len(uniques))
+	for i, cols := range uniques {
+		parts := make([]string, len(cols))
+		for j, col := range cols {
+			if row[col] == nil {
+				// NULLs never conflict
+				parts = nil
+				break
+			}
+			parts[j] = fmt.Sprint(row[col])
+		}
+		if parts == nil {
+			continue
+		}
+		keys[i] = strings.Join(parts, "\x00")
+		if seen[i][keys[i]] {
+			return false
+		}
+	}
+
+	for i, key := range keys {
+		if key != "" {
+			seen[i][key] = true
+		}
+	}
+	return true
+}
+
+// adjusts the row so the parsed checks hold, NULLs satisfy any check
+func applyChecks(row map[string]any, checks []columnCheck) {
+	for _, check := range checks {
+		left := row[check.left]
+		if left == nil {
+			continue
+		}
+
+		if check.right != "" {
+			right := row[check.right]
+			if right == nil {
+				continue
+			}
+			row[check.left] = satisfy(left, check.op, right)
+			continue
+		}
+
+		row[check.left] = satisfy(left, check.op, *check.number)
+	}
+}
+
+// returns left, or the closest value to it for which "left op right" holds
+func satisfy(left any, op string, right any) any {
+	switch l := left.(type) {
+	case time.Time:
+		r, ok := right.(time.Time)
+		if !ok {
+			return left
+		}
+		switch {
+		case op == ">=" && l.Before(r), op == "<=" && l.After(r), op == "=":
+			return r
+		case op == ">" && !l.After(r):
+			return r.Add(time.Second)
+		case op == "<" && !l.Before(r):
+			return r.Add(-time.Second)
+		case op == "<>" && l.Equal(r):
+			return l.Add(time.Second)
+		}
+		return l
+	case int, float64:
+		lf := toFloat(l)
+		rf := toFloat(right)
+		var result float64 = lf
+		switch {
+		case op == ">=" && lf < rf, op == "<=" && lf > rf, op == "=":
+			result = rf
+		case op == ">" && lf <= rf:
+			result = rf + 1
+		case op == "<" && lf >= rf:
+			result = rf - 1
+		case op == "<>" && lf == rf:
+			result = lf + 1
+		}
+		if _, isInt := l.(int); isInt {
+			return int(result)
+		}
+		return result
+	}
+	return left
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+func isInteger(c *database.Column) bool {
+	switch strings.ToLower(c.Type) {
+	case "smallint", "integer", "bigint", "int":
+		return true
+	}
+	return false
+}
+
+// whether the generator can produce values for the column
+func supportedType(c *database.Column) bool {
+	if len(c.Enum) > 0 || isInteger(c) {
+		return true
+	}
+	typ := strings.ToLower(c.Type)
+	for _, prefix := range []string{"numeric", "decimal", "real", "double precision", "boolean", "text", "character", "varchar", "timestamp", "date", "uuid", "json", "bytea"} {
+		if strings.HasPrefix(typ, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// generates a value for the column based on its type. row is the index of the row being generated
+// and keeps unique integer and text columns unique.
+func (s *SchemaSeeder) value(c *database.Column, row int) any {
+	if !c.NotNull && !c.Unique && s.Rand.Intn(100) < s.Cfg.nullRate {
+		return nil
+	}
+
+	if len(c.Enum) > 0 {
+		return c.Enum[s.Rand.Intn(len(c.Enum))]
+	}
+
+	if isInteger(c) {
+		// counting up from 1 never collides, drawing would need retries once the range fills up
+		if c.Unique {
+			return row + 1
+		}
+		return Uniform{Min: 1, Max: 10_000}.Sample(s.Rand)
+	}
+
+	typ := strings.ToLower(c.Type)
+	switch {
+	case strings.HasPrefix(typ, "numeric"), strings.HasPrefix(typ, "decimal"), strings.HasPrefix(typ, "real"), strings.HasPrefix(typ, "double precision"):
+		return float64(s.Rand.Intn(1_000_000)) / 100
+	case strings.HasPrefix(typ, "boolean"):
+		return s.Rand.Intn(2) == 1
+	case strings.HasPrefix(typ, "timestamp"), strings.HasPrefix(typ, "date"):
+		window := s.Cfg.until.Sub(s.Cfg.since)
+		if window <= 0 {
+			return s.Cfg.since
+		}
+		return s.Cfg.since.Add(time.Duration(s.Rand.Int63n(int64(window))))
+	case strings.HasPrefix(typ, "uuid"):
+		b := make([]byte, 16)
+		s.Rand.Read(b)
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	case strings.HasPrefix(typ, "json"):
+		return fmt.Sprintf(`{"value": %q}`, s.lorem.GenerateWord())
+	case strings.HasPrefix(typ, "bytea"):
+		b := make([]byte, 16)
+		s.Rand.Read(b)
+		return b
+	}
+
+	text := s.lorem.GenerateSentence()
+	if c.Unique {
+		suffix := "-" + strconv.Itoa(row+1)
+		if c.Length > 0 && len(text)+len(suffix) > c.Length {
+			keep := c.Length - len(suffix)
+			if keep < 0 {
+				keep = 0
+			}
+			text = text[:keep]
+		}
+		return text + suffix
+	}
+	if c.Length > 0 && len(text) > c.Length {
+		text = text[:c.Length]
+	}
+	return text
+}
+
+// sets the deferred references of a seeded table now that every table has rows. the rows are
+// updated in the order they were copied in, the new table has them in that order on disk.
+func (s *SchemaSeeder) fillDeferred(ctx context.Context, t *database.Table) error {
+	deferred := []*database.Reference{}
+	for _, ref := range t.References {
+		if ref.Deferred {
+			deferred = append(deferred, ref)
+		}
+	}
+	if len(deferred) == 0 {
+		return nil
+	}
+
+	tx, err := s.Store.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT ctid::text FROM %s ORDER BY ctid", pq.QuoteIdentifier(t.Name)))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	tids := []string{}
+	for rows.Next() {
+		var tid string
+		if err := rows.Scan(&tid); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+		tids = append(tids, tid)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	set := make([]string, len(deferred))
+	for i, ref := range deferred {
+		set[i] = fmt.Sprintf("%s = $%d", pq.QuoteIdentifier(ref.Column), i+1)
+	}
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("UPDATE %s SET %s WHERE ctid = $%d::tid",
+		pq.QuoteIdentifier(t.Name), strings.Join(set, ", "), len(deferred)+1))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	cursors := map[string]int{}
+	for _, tid := range tids {
+		values := make([]any, 0, len(deferred)+1)
+		for _, ref := range deferred {
+			value, _, err := s.reference(t, t.Column(ref.Column), ref, cursors)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			values = append(values, value)
+		}
+		values = append(values, tid)
+
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// COPYs the rows into the table in a single transaction
+func (s *SchemaSeeder) copyRows(ctx context.Context, table string, columns []string, rows [][]any) error {
+	tx, err := s.Store.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := stmt.Exec(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := stmt.Close(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,201 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/* POPULARITY */
+/**************/
+
+// Popularity is how unevenly posts are spread over the accounts, threads or boards. with uniform
+// every one of them is as likely to get the next post as any other, with zipf the one ranked r gets
+// posts in proportion to 1/r^exponent and with pareto every one of them is given a heavy-tailed
+// weight with the exponent as the shape, the lower the exponent the fewer get most of the posts.
+type Popularity struct {
+	// uniform, zipf or pareto
+	Distribution string `json:"distribution"`
+
+	// the exponent of zipf or the shape of pareto, unused by uniform
+	Exponent float64 `json:"exponent"`
+}
+
+var (
+	popularity_distributions = []string{"uniform", "zipf", "pareto"}
+
+	// a pareto weight is at most 2^(53/exponent), the minimum keeps it and the sum of any number of
+	// them far from overflowing a float64
+	pareto_min_exponent = 0.1
+
+	// a few prolific posters and a long tail of lurkers, a few hot threads among many quiet ones and
+	// boards that are busier than others without any of them taking over
+	default_popularity = map[string]*Popularity{
+		"accounts": {Distribution: "zipf", Exponent: 1},
+		"threads":  {Distribution: "pareto", Exponent: 1.5},
+		"boards":   {Distribution: "zipf", Exponent: 0.8},
+	}
+)
+
+// reads a popularity written as distribution:exponent, zipf:1.2, or just the distribution for
+// uniform
+func ParsePopularity(v string) (*Popularity, error) {
+	name, exponent, ok := strings.Cut(v, ":")
+	p := &Popularity{Distribution: strings.TrimSpace(name)}
+	if ok {
+		e, err := strconv.ParseFloat(strings.TrimSpace(exponent), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent in %q: %v", v, err)
+		}
+		p.Exponent = e
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks the distribution is known and has the exponent it needs
+func (p *Popularity) Validate() error {
+	switch p.Distribution {
+	case "uniform":
+		return nil
+	case "zipf":
+		if p.Exponent <= 0 {
+			return fmt.Errorf("zipf needs an exponent above 0, got %v", p.Exponent)
+		}
+		return nil
+	case "pareto":
+		if p.Exponent < pareto_min_exponent {
+			return fmt.Errorf("pareto needs an exponent of at least %v, got %v", pareto_min_exponent, p.Exponent)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown distribution %q, expected one of %s", p.Distribution, strings.Join(popularity_distributions, ", "))
+}
+
+// sets how posts are spread over the accounts, threads or boards
+func SeederCfgSetPopularity(of string, p *Popularity) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		popularity := make(map[string]*Popularity, len(c.popularity)+1)
+		for k, v := range c.popularity {
+			popularity[k] = v
+		}
+		popularity[of] = p
+		c.popularity = popularity
+		return c
+	}
+}
+
+// what a popularity can be set for
+func popularityTargets() []string {
+	targets := make([]string, 0, len(default_popularity))
+	for t := range default_popularity {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// checks every target has a valid popularity, the seeder draws from all of them
+func checkPopularity(popularity map[string]*Popularity) []error {
+	var errs []error
+	for _, of := range popularityTargets() {
+		if _, ok := popularity[of]; !ok {
+			errs = append(errs, fmt.Errorf("popularity of %s is not set", of))
+		}
+	}
+	for of, p := range popularity {
+		if _, ok := default_popularity[of]; !ok {
+			errs = append(errs, fmt.Errorf("unknown popularity %q, expected one of %s", of, strings.Join(popularityTargets(), ", ")))
+			continue
+		}
+		if p == nil {
+			errs = append(errs, fmt.Errorf("popularity of %s is not set", of))
+			continue
+		}
+		if err := p.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("popularity of %s: %w", of, err))
+		}
+	}
+	return errs
+}
+
+// draws one of n accounts, threads or boards. zipf ranks them in a random order so being first
+// doesn't make anything popular.
+func (p *Popularity) distribution(r *rand.Rand, n int) Distribution {
+	switch p.Distribution {
+	case "zipf":
+		return ranked{order: r.Perm(n), dist: NewZipf(n, p.Exponent)}
+	case "pareto":
+		weights := make([]float64, n)
+		for i := range weights {
+			// 1-Float64 is never 0, the weight never infinite
+			weights[i] = math.Pow(1-r.Float64(), -1/p.Exponent)
+		}
+		return NewAlias(weights)
+	}
+	return Uniform{Min: 0, Max: n}
+}
+
+// ReferenceError reports a pick of an account, board or thread that isn't among the ones seeded,
+// Count is 0 when there was none to pick from
+type ReferenceError struct {
+	Entity string
+	Index  int
+	Count  int
+}
+
+func (e *ReferenceError) Error() string {
+	if e.Count == 0 {
+		return fmt.Sprintf("reference exception: no %s to pick from", e.Entity)
+	}
+	return fmt.Sprintf("reference exception: out of bounds %s index %d of %d", e.Entity, e.Index, e.Count)
+}
+
+// draws the index of one of the n entities from the distribution
+func pick(r *rand.Rand, entity string, n int, dist Distribution) (int, error) {
+	if n == 0 {
+		return 0, &ReferenceError{Entity: entity}
+	}
+	i := dist.Sample(r)
+	if i < 0 || i >= n {
+		return 0, &ReferenceError{Entity: entity, Index: i, Count: n}
+	}
+	return i, nil
+}
+
+// the account making the next thread or post, the more active an account the more likely
+func (s *Seeder) activeAccount() (*Account, error) {
+	if s.activity == nil {
+		s.activity = s.Cfg.popularity["accounts"].distribution(s.Rand, len(s.Accounts))
+	}
+	i, err := pick(s.Rand, "account", len(s.Accounts), s.activity)
+	if err != nil {
+		return nil, err
+	}
+	return s.Accounts[i], nil
+}
+
+// the share of the posts made by the given share of the accounts that posted the most
+func (s *Seeder) topPosterShare(top float64) float64 {
+	posts := make([]int, len(s.Accounts))
+	total := 0
+	for i, a := range s.Accounts {
+		posts[i] = a.posts
+		total += a.posts
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(posts)))
+
+	made := 0
+	for _, n := range posts[:int(math.Ceil(float64(len(posts))*top))] {
+		made += n
+	}
+	return float64(made) / float64(total)
+}
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestPickReferenceErrors(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name string
+		n    int
+		dist Distribution
+		want ReferenceError
+	}{
+		{"nothing to pick from", 0, Uniform{Min: 0, Max: 0}, ReferenceError{Entity: "thread"}},
+		{"no weights", 3, NewAlias([]float64{0, 0, 0}), ReferenceError{Entity: "thread", Index: -1, Count: 3}},
+		{"past the entities", 2, Uniform{Min: 5, Max: 6}, ReferenceError{Entity: "thread", Index: 5, Count: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pick(r, "thread", tt.n, tt.dist)
+			var refErr *ReferenceError
+			if !errors.As(err, &refErr) {
+				t.Fatalf("expected a *ReferenceError, got %v", err)
+			}
+			if *refErr != tt.want {
+				t.Errorf("got %+v, expected %+v", *refErr, tt.want)
+			}
+		})
+	}
+
+	if i, err := pick(r, "thread", 4, Uniform{Min: 0, Max: 4}); err != nil || i < 0 || i >= 4 {
+		t.Errorf("picked %d of 4 with error %v", i, err)
+	}
+}
+
+func TestActiveAccountWithoutAccounts(t *testing.T) {
+	s, err := NewSeeder(nil, SeederCfgSetSeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var refErr *ReferenceError
+	if _, err := s.activeAccount(); !errors.As(err, &refErr) || refErr.Entity != "account" {
+		t.Errorf("expected a *ReferenceError for the account, got %v", err)
+	}
+}
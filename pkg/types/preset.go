@@ -0,0 +1,129 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/* SEEDER PRESETS */
+/******************/
+
+// Preset is a named size of dataset. the counts of each are picked so the proportions look like a
+// real forum: a few boards, many threads per board, many more posts per thread and a lot fewer
+// accounts than posts. the expected rows are with the default boards and accounts.
+type Preset string
+
+const (
+	// unit and integration tests, seeds in well under a second
+	//	~20 accounts, ~2 articles, ~20 threads, ~55 posts, ~50 identities
+	PresetTiny Preset = "tiny"
+
+	// local development, big enough to page through
+	//	~80 accounts, ~10 articles, ~235 threads, ~2.8k posts, ~2.5k identities
+	PresetDev Preset = "dev"
+
+	// a staging database, about twice the posts of the defaults spread over ten times the accounts
+	//	~1.5k accounts, ~125 articles, ~2.2k threads, ~110k posts, ~110k identities
+	PresetStaging Preset = "staging"
+
+	// load testing, streamed and committed in chunks so memory stays bounded
+	//	~75k accounts, ~750 articles, ~33k threads, ~4.1M posts, ~4M identities
+	PresetLoad Preset = "load"
+)
+
+type presetConfig struct {
+	minAccountCount   int
+	maxAccountCount   int
+	minArticleCount   int
+	maxArticleCount   int
+	minThreadPerBoard int
+	maxThreadPerBoard int
+	minPostPerThread  int
+	maxPostPerThread  int
+
+	stream    bool
+	batchSize int
+}
+
+var seeder_presets = map[Preset]presetConfig{
+	PresetTiny: {
+		minAccountCount: 10, maxAccountCount: 20,
+		minArticleCount: 1, maxArticleCount: 4,
+		minThreadPerBoard: 1, maxThreadPerBoard: 3,
+		minPostPerThread: 1, maxPostPerThread: 4,
+	},
+	PresetDev: {
+		minAccountCount: 50, maxAccountCount: 100,
+		minArticleCount: 5, maxArticleCount: 20,
+		minThreadPerBoard: 10, maxThreadPerBoard: 30,
+		minPostPerThread: 2, maxPostPerThread: 20,
+	},
+	PresetStaging: {
+		minAccountCount: 1_000, maxAccountCount: 2_000,
+		minArticleCount: 50, maxArticleCount: 200,
+		minThreadPerBoard: 100, maxThreadPerBoard: 300,
+		minPostPerThread: 3, maxPostPerThread: 100,
+	},
+	PresetLoad: {
+		minAccountCount: 50_000, maxAccountCount: 100_000,
+		minArticleCount: 500, maxArticleCount: 1_000,
+		minThreadPerBoard: 2_000, maxThreadPerBoard: 4_000,
+		minPostPerThread: 50, maxPostPerThread: 200,
+		stream:    true,
+		batchSize: 100_000,
+	},
+}
+
+func (p Preset) String() string {
+	return string(p)
+}
+
+// the names of every preset, smallest first
+func PresetNames() []string {
+	names := make([]string, 0, len(seeder_presets))
+	for p := range seeder_presets {
+		names = append(names, p.String())
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return seeder_presets[Preset(names[i])].maxPostPerThread < seeder_presets[Preset(names[j])].maxPostPerThread
+	})
+	return names
+}
+
+// looks a preset up by its name
+func ParsePreset(name string) (Preset, error) {
+	p := Preset(name)
+	if _, ok := seeder_presets[p]; !ok {
+		return "", fmt.Errorf("unknown preset %q, expected one of %s", name, strings.Join(PresetNames(), ", "))
+	}
+	return p, nil
+}
+
+// sets the counts, and for the load preset streaming and the batch size, of a preset. options
+// given after it override it.
+func SeederCfgSetPreset(p Preset) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		preset, ok := seeder_presets[p]
+		if !ok {
+			_, c.err = ParsePreset(p.String())
+			return c
+		}
+
+		c.minAccountCount = preset.minAccountCount
+		c.maxAccountCount = preset.maxAccountCount
+		c.minArticleCount = preset.minArticleCount
+		c.maxArticleCount = preset.maxArticleCount
+		c.minThreadPerBoard = preset.minThreadPerBoard
+		c.maxThreadPerBoard = preset.maxThreadPerBoard
+		c.minPostPerThread = preset.minPostPerThread
+		c.maxPostPerThread = preset.maxPostPerThread
+
+		if preset.stream {
+			c.stream = true
+			c.batchSize = preset.batchSize
+		}
+
+		return c
+	}
+}
@@ -0,0 +1,591 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/* SEED PROFILES */
+/*****************/
+
+// Profile is a dataset described in a JSON file: how much of everything to generate, the weights
+// of the generated values, the boards and accounts every dataset starts with and the shape of the
+// generated text. everything is optional, whatever a profile leaves out keeps its default.
+//
+//	{
+//	  "counts": {"min_accounts": 10, "max_accounts": 20, "max_posts_per_thread": 5},
+//	  "weights": {"account_role": {"user": 95, "moderator": 5}, "email_domain": {"example.com": 1}},
+//	  "boards": [{"title": "general", "short": "gen", "description": "general discussion"}],
+//	  "accounts": [{"username": "qa", "email": "qa@example.com", "role": "admin"}],
+//	  "lorem": {"max_paragraphs": 2}
+//	}
+type Profile struct {
+	Seed     *int64           `json:"seed"`
+	Counts   *ProfileCounts   `json:"counts"`
+	Weights  *ProfileWeights  `json:"weights"`
+	Boards   []ProfileBoard   `json:"boards"`
+	Accounts []ProfileAccount `json:"accounts"`
+	Lorem    *ProfileLorem    `json:"lorem"`
+
+	// share of soft-deleted rows by table, and by status as {"account_status": {"banned": 1}}
+	DeleteRates       map[string]float64            `json:"delete_rates"`
+	StatusDeleteRates map[string]map[string]float64 `json:"status_delete_rates"`
+
+	// when threads are opened and replied to, replaces the default traffic model entirely
+	Traffic *Traffic `json:"traffic"`
+
+	// how posts are spread over the accounts, threads and boards, as {"accounts": {"distribution":
+	// "zipf", "exponent": 1.2}}
+	Popularity map[string]*Popularity `json:"popularity"`
+}
+
+type ProfileCounts struct {
+	MinAccounts        *int `json:"min_accounts"`
+	MaxAccounts        *int `json:"max_accounts"`
+	MinArticles        *int `json:"min_articles"`
+	MaxArticles        *int `json:"max_articles"`
+	MinThreadsPerBoard *int `json:"min_threads_per_board"`
+	MaxThreadsPerBoard *int `json:"max_threads_per_board"`
+
+	// the average of replies per thread of a board, the popularity of the threads spreads them
+	MinPostsPerThread *int `json:"min_posts_per_thread"`
+	MaxPostsPerThread *int `json:"max_posts_per_thread"`
+}
+
+// weights by enum value (or email domain), a weight map replaces the default one entirely
+type ProfileWeights struct {
+	AccountRole    map[string]int `json:"account_role"`
+	AccountStatus  map[string]int `json:"account_status"`
+	ArticleStatus  map[string]int `json:"article_status"`
+	ThreadStatus   map[string]int `json:"thread_status"`
+	ThreadRole     map[string]int `json:"thread_role"`
+	IdentityStatus map[string]int `json:"identity_status"`
+	EmailDomain    map[string]int `json:"email_domain"`
+}
+
+type ProfileBoard struct {
+	Title       string `json:"title"`
+	Short       string `json:"short"`
+	Description string `json:"description"`
+}
+
+type ProfileAccount struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+type ProfileLorem struct {
+	MinWordLength      *int `json:"min_word_length"`
+	MaxWordLength      *int `json:"max_word_length"`
+	MinSentenceLength  *int `json:"min_sentence_length"`
+	MaxSentenceLength  *int `json:"max_sentence_length"`
+	MinParagraphLength *int `json:"min_paragraph_length"`
+	MaxParagraphLength *int `json:"max_paragraph_length"`
+	MinParagraphs      *int `json:"min_paragraphs"`
+	MaxParagraphs      *int `json:"max_paragraphs"`
+}
+
+// ProfileError points at the key of a profile that's wrong, like counts.max_accounts or
+// boards[2].short
+type ProfileError struct {
+	Path    string
+	Key     string
+	Message string
+}
+
+func (e *ProfileError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("profile %s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("profile %s: %s: %s", e.Path, e.Key, e.Message)
+}
+
+// LoadProfile reads and validates a profile file. unknown keys, values of the wrong type and
+// values that can't be seeded are all reported with the key they're at. only JSON is read, a file
+// with another extension is refused before it's read.
+func LoadProfile(path string) (*Profile, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" && ext != "" {
+		return nil, &ProfileError{Path: path, Message: fmt.Sprintf("only JSON profiles are supported, not %s", ext)}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, &ProfileError{Path: path, Message: err.Error()}
+	}
+	if err := checkKeys(raw, reflect.TypeOf(Profile{}), ""); err != nil {
+		err.Path = path
+		return nil, err
+	}
+
+	p := &Profile{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(p); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, &ProfileError{Path: path, Key: typeErr.Field, Message: fmt.Sprintf("expected %v, got %v", typeErr.Type, typeErr.Value)}
+		}
+		return nil, &ProfileError{Path: path, Message: err.Error()}
+	}
+
+	if err := p.Validate(); err != nil {
+		var profileErr *ProfileError
+		if errors.As(err, &profileErr) {
+			profileErr.Path = path
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// walks the decoded json next to the type it's decoded into and reports the first key the type
+// doesn't have
+func checkKeys(v any, t reflect.Type, key string) *ProfileError {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		fields := map[string]reflect.Type{}
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			fields[name] = t.Field(i).Type
+		}
+
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			path := joinKey(key, k)
+			ft, ok := fields[k]
+			if !ok {
+				return &ProfileError{Key: path, Message: "unknown key"}
+			}
+			if err := checkKeys(obj[k], ft, path); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if err := checkKeys(obj[k], t.Elem(), joinKey(key, k)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		list, ok := v.([]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range list {
+			if err := checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", key, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func joinKey(parent string, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// the values every enum weight map accepts, by its key
+func profileEnums() map[string]map[string]Enum {
+	enums := map[string]map[string]Enum{
+		"account_role":    {},
+		"account_status":  {},
+		"article_status":  {},
+		"thread_status":   {},
+		"thread_role":     {},
+		"identity_status": {},
+	}
+	for _, v := range AccountRoleID {
+		enums["account_role"][v.String()] = v
+	}
+	for _, v := range AccountStatusID {
+		enums["account_status"][v.String()] = v
+	}
+	for _, v := range ArticleStatusID {
+		enums["article_status"][v.String()] = v
+	}
+	for _, v := range ThreadStatusID {
+		enums["thread_status"][v.String()] = v
+	}
+	for _, v := range ThreadRoleID {
+		enums["thread_role"][v.String()] = v
+	}
+	for _, v := range IdentityStatusID {
+		enums["identity_status"][v.String()] = v
+	}
+	return enums
+}
+
+// the enum weight maps of the profile by their key
+func (w *ProfileWeights) enums() map[string]map[string]int {
+	return map[string]map[string]int{
+		"account_role":    w.AccountRole,
+		"account_status":  w.AccountStatus,
+		"article_status":  w.ArticleStatus,
+		"thread_status":   w.ThreadStatus,
+		"thread_role":     w.ThreadRole,
+		"identity_status": w.IdentityStatus,
+	}
+}
+
+// Validate checks the profile describes something that can be seeded: ranges that aren't upside
+// down, weight maps with known values and something to pick, and boards and accounts that fit
+// their columns without clashing.
+func (p *Profile) Validate() error {
+	if c := p.Counts; c != nil {
+		ranges := []struct {
+			key      string
+			min, max *int
+		}{
+			{"accounts", c.MinAccounts, c.MaxAccounts},
+			{"articles", c.MinArticles, c.MaxArticles},
+			{"threads_per_board", c.MinThreadsPerBoard, c.MaxThreadsPerBoard},
+			{"posts_per_thread", c.MinPostsPerThread, c.MaxPostsPerThread},
+		}
+		for _, r := range ranges {
+			if err := checkRange("counts", r.key, r.min, r.max, 0); err != nil {
+				return err
+			}
+		}
+	}
+
+	if l := p.Lorem; l != nil {
+		ranges := []struct {
+			key      string
+			min, max *int
+		}{
+			{"word_length", l.MinWordLength, l.MaxWordLength},
+			{"sentence_length", l.MinSentenceLength, l.MaxSentenceLength},
+			{"paragraph_length", l.MinParagraphLength, l.MaxParagraphLength},
+			{"paragraphs", l.MinParagraphs, l.MaxParagraphs},
+		}
+		for _, r := range ranges {
+			if err := checkRange("lorem", r.key, r.min, r.max, 1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if w := p.Weights; w != nil {
+		enums := profileEnums()
+		for key, weights := range w.enums() {
+			if weights == nil {
+				continue
+			}
+			for value := range weights {
+				if _, ok := enums[key][value]; !ok {
+					return &ProfileError{Key: "weights." + key + "." + value, Message: fmt.Sprintf("unknown %s, expected one of %s", strings.ReplaceAll(key, "_", " "), enumNames(enums[key]))}
+				}
+			}
+			if err := checkWeights("weights."+key, weights); err != nil {
+				return err
+			}
+		}
+		if w.EmailDomain != nil {
+			if err := checkWeights("weights.email_domain", w.EmailDomain); err != nil {
+				return err
+			}
+		}
+	}
+
+	tables := deleteTables()
+	for table, rate := range p.DeleteRates {
+		key := "delete_rates." + table
+		if _, ok := default_delete_rates[table]; !ok {
+			return &ProfileError{Key: key, Message: fmt.Sprintf("unknown table, expected one of %s", strings.Join(tables, ", "))}
+		}
+		if err := checkRate(key, rate); err != nil {
+			return err
+		}
+	}
+
+	enums := profileEnums()
+	for kind, rates := range p.StatusDeleteRates {
+		key := "status_delete_rates." + kind
+		if !strings.HasSuffix(kind, "_status") || enums[kind] == nil {
+			return &ProfileError{Key: key, Message: "unknown status, expected one of account_status, article_status, thread_status, identity_status"}
+		}
+		for value, rate := range rates {
+			if _, ok := enums[kind][value]; !ok {
+				return &ProfileError{Key: key + "." + value, Message: fmt.Sprintf("unknown %s, expected one of %s", strings.ReplaceAll(kind, "_", " "), enumNames(enums[kind]))}
+			}
+			if err := checkRate(key+"."+value, rate); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.Traffic != nil {
+		if err := p.Traffic.Validate(); err != nil {
+			return &ProfileError{Key: "traffic", Message: err.Error()}
+		}
+	}
+
+	for of, pop := range p.Popularity {
+		key := "popularity." + of
+		if _, ok := default_popularity[of]; !ok {
+			return &ProfileError{Key: key, Message: fmt.Sprintf("unknown key, expected one of %s", strings.Join(popularityTargets(), ", "))}
+		}
+		if pop == nil {
+			return &ProfileError{Key: key, Message: "needs a distribution"}
+		}
+		if err := pop.Validate(); err != nil {
+			return &ProfileError{Key: key, Message: err.Error()}
+		}
+	}
+
+	titles := map[string]bool{}
+	shorts := map[string]bool{}
+	for i, b := range p.Boards {
+		key := fmt.Sprintf("boards[%d]", i)
+		checks := []struct {
+			field string
+			value string
+			max   int
+			seen  map[string]bool
+		}{
+			{"title", b.Title, 63, titles},
+			{"short", b.Short, 7, shorts},
+			{"description", b.Description, 255, nil},
+		}
+		for _, c := range checks {
+			if err := checkText(key+"."+c.field, c.value, c.max, c.seen); err != nil {
+				return err
+			}
+		}
+	}
+
+	usernames := map[string]bool{}
+	emails := map[string]bool{}
+	roles := profileEnums()["account_role"]
+	for i, a := range p.Accounts {
+		key := fmt.Sprintf("accounts[%d]", i)
+		if err := checkText(key+".username", a.Username, 31, usernames); err != nil {
+			return err
+		}
+		if err := checkText(key+".email", a.Email, 255, emails); err != nil {
+			return err
+		}
+		if _, ok := roles[a.Role]; !ok {
+			return &ProfileError{Key: key + ".role", Message: fmt.Sprintf("unknown account role %q, expected one of %s", a.Role, enumNames(roles))}
+		}
+	}
+
+	return nil
+}
+
+// checks min_<key> and max_<key> of a section, either may be left out
+func checkRange(section string, key string, min *int, max *int, lowest int) error {
+	if min != nil && *min < lowest {
+		return &ProfileError{Key: section + ".min_" + key, Message: fmt.Sprintf("must be at least %d", lowest)}
+	}
+	if max != nil && *max < lowest {
+		return &ProfileError{Key: section + ".max_" + key, Message: fmt.Sprintf("must be at least %d", lowest)}
+	}
+	if min != nil && max != nil && *max < *min {
+		return &ProfileError{Key: section + ".max_" + key, Message: fmt.Sprintf("%d can't be below %s.min_%s %d, the max is excluded unless it equals the min", *max, section, key, *min)}
+	}
+	return nil
+}
+
+func checkRate(key string, rate float64) error {
+	if rate < 0 || rate > 1 {
+		return &ProfileError{Key: key, Message: fmt.Sprintf("rate must be between 0 and 1, got %v", rate)}
+	}
+	return nil
+}
+
+func checkWeights(key string, weights map[string]int) error {
+	total := 0
+	for value, weight := range weights {
+		if weight < 0 {
+			return &ProfileError{Key: key + "." + value, Message: "weight can't be negative"}
+		}
+		total += weight
+	}
+	if total == 0 {
+		return &ProfileError{Key: key, Message: "needs at least one value with a weight above 0"}
+	}
+	return nil
+}
+
+func checkText(key string, value string, max int, seen map[string]bool) error {
+	if value == "" {
+		return &ProfileError{Key: key, Message: "can't be empty"}
+	}
+	if len(value) > max {
+		return &ProfileError{Key: key, Message: fmt.Sprintf("longer than %d characters", max)}
+	}
+	if seen != nil {
+		if seen[value] {
+			return &ProfileError{Key: key, Message: fmt.Sprintf("duplicate %q", value)}
+		}
+		seen[value] = true
+	}
+	return nil
+}
+
+func enumNames(values map[string]Enum) string {
+	list := make([]Enum, 0, len(values))
+	for _, v := range values {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Int() < list[j].Int() })
+
+	names := make([]string, len(list))
+	for i, v := range list {
+		names[i] = v.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// SeederCfgSetProfile applies a validated profile on top of the configuration so far
+func SeederCfgSetProfile(p *Profile) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.profile = p
+
+		if p.Seed != nil {
+			c.seed = *p.Seed
+		}
+
+		if counts := p.Counts; counts != nil {
+			setInt(&c.minAccountCount, counts.MinAccounts)
+			setInt(&c.maxAccountCount, counts.MaxAccounts)
+			setInt(&c.minArticleCount, counts.MinArticles)
+			setInt(&c.maxArticleCount, counts.MaxArticles)
+			setInt(&c.minThreadPerBoard, counts.MinThreadsPerBoard)
+			setInt(&c.maxThreadPerBoard, counts.MaxThreadsPerBoard)
+			setInt(&c.minPostPerThread, counts.MinPostsPerThread)
+			setInt(&c.maxPostPerThread, counts.MaxPostsPerThread)
+		}
+
+		if w := p.Weights; w != nil {
+			weights := *c.weights
+			enums := profileEnums()
+			targets := map[string]*map[Enum]int{
+				"account_role":    &weights.accountRole,
+				"account_status":  &weights.accountStatus,
+				"article_status":  &weights.articleStatus,
+				"thread_status":   &weights.threadStatus,
+				"thread_role":     &weights.threadRole,
+				"identity_status": &weights.identityStatus,
+			}
+			for key, values := range w.enums() {
+				if values == nil {
+					continue
+				}
+				m := map[Enum]int{}
+				for value, weight := range values {
+					m[enums[key][value]] = weight
+				}
+				*targets[key] = m
+			}
+			if w.EmailDomain != nil {
+				weights.emailDomain = w.EmailDomain
+			}
+			c.weights = &weights
+		}
+
+		if p.Boards != nil {
+			c.boards = [][]string{}
+			for _, b := range p.Boards {
+				c.boards = append(c.boards, []string{b.Title, b.Short, b.Description})
+			}
+		}
+
+		if p.Accounts != nil {
+			c.accounts = [][]string{}
+			for _, a := range p.Accounts {
+				c.accounts = append(c.accounts, []string{a.Username, a.Email, a.Role})
+			}
+		}
+
+		if l := p.Lorem; l != nil {
+			fields := []struct {
+				value *int
+				fn    func(int) LoremConfigFunc
+			}{
+				{l.MinWordLength, LoremMinWordLength},
+				{l.MaxWordLength, LoremMaxWordLength},
+				{l.MinSentenceLength, LoremMinSentenceLength},
+				{l.MaxSentenceLength, LoremMaxSentenceLength},
+				{l.MinParagraphLength, LoremMinParagraphLength},
+				{l.MaxParagraphLength, LoremMaxParagraphLength},
+				{l.MinParagraphs, LoremMinParagraphCount},
+				{l.MaxParagraphs, LoremMaxParagraphCount},
+			}
+			for _, f := range fields {
+				if f.value != nil {
+					c.lorem = append(c.lorem, f.fn(*f.value))
+				}
+			}
+		}
+
+		for table, rate := range p.DeleteRates {
+			c = SeederCfgSetDeleteRate(table, rate)(c)
+		}
+
+		if p.Traffic != nil {
+			c.traffic = p.Traffic
+		}
+
+		for of, pop := range p.Popularity {
+			c = SeederCfgSetPopularity(of, pop)(c)
+		}
+
+		enums := profileEnums()
+		for kind, rates := range p.StatusDeleteRates {
+			for value, rate := range rates {
+				c = SeederCfgSetStatusDeleteRate(enums[kind][value], rate)(c)
+			}
+		}
+
+		return c
+	}
+}
+
+func setInt(dst *int, v *int) {
+	if v != nil {
+		*dst = *v
+	}
+}
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		key     string
+		message string
+	}{
+		{"unknown key", "p.json", `{"seeds": 1}`, "seeds", "unknown key"},
+		{"unknown nested key", "p.json", `{"counts": {"max_acounts": 1}}`, "counts.max_acounts", "unknown key"},
+		{"unknown key in a list", "p.json", `{"boards": [{"title": "a", "short": "a", "description": "a"}, {"titel": "b"}]}`, "boards[1].titel", "unknown key"},
+		{"unknown key in a map", "p.json", `{"popularity": {"threads": {"distribution": "zipf", "exponnt": 1}}}`, "popularity.threads.exponnt", "unknown key"},
+		{"wrong type", "p.json", `{"counts": {"max_accounts": "ten"}}`, "counts.max_accounts", "expected int"},
+		{"wrong type of a list", "p.json", `{"boards": {"title": "a"}}`, "boards", "expected []types.ProfileBoard"},
+		{"range upside down", "p.json", `{"counts": {"min_accounts": 5, "max_accounts": 4}}`, "counts.max_accounts", "can't be below counts.min_accounts 5"},
+		{"unknown enum value", "p.json", `{"weights": {"account_role": {"owner": 1}}}`, "weights.account_role.owner", "unknown account role"},
+		{"invalid json", "p.json", `{"counts": `, "", "unexpected end of JSON input"},
+		{"yaml", "p.yaml", "counts:\n  max_accounts: 1\n", "", "only JSON profiles are supported, not .yaml"},
+		{"toml", "p.toml", "[counts]\nmax_accounts = 1\n", "", "only JSON profiles are supported, not .toml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadProfile(path)
+			var profileErr *ProfileError
+			if !errors.As(err, &profileErr) {
+				t.Fatalf("expected a *ProfileError, got %v", err)
+			}
+			if profileErr.Key != tt.key {
+				t.Errorf("error at key %q, expected %q: %v", profileErr.Key, tt.key, err)
+			}
+			if !strings.Contains(profileErr.Message, tt.message) {
+				t.Errorf("message %q doesn't contain %q", profileErr.Message, tt.message)
+			}
+			if profileErr.Path != path {
+				t.Errorf("error names %q, expected the file %q", profileErr.Path, path)
+			}
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qa.json")
+	content := `{
+	  "seed": 42,
+	  "counts": {"min_accounts": 2, "max_accounts": 2},
+	  "weights": {"account_role": {"user": 9, "admin": 1}},
+	  "boards": [{"title": "qa", "short": "qa", "description": "the board the QA suite posts to"}],
+	  "popularity": {"threads": {"distribution": "zipf", "exponent": 1.2}}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadProfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Seed == nil || *p.Seed != 42 {
+		t.Errorf("seed read as %v", p.Seed)
+	}
+	if len(p.Boards) != 1 || p.Boards[0].Short != "qa" {
+		t.Errorf("boards read as %+v", p.Boards)
+	}
+
+	c := SeederCfgSetProfile(p)(defaultSeederConfig())
+	if err := c.Validate(); err != nil {
+		t.Errorf("config of the profile is invalid: %v", err)
+	}
+	if c.minAccountCount != 2 || c.maxAccountCount != 2 {
+		t.Errorf("account count set to %d-%d", c.minAccountCount, c.maxAccountCount)
+	}
+	if c.popularity["threads"].Exponent != 1.2 || c.popularity["accounts"] == nil {
+		t.Errorf("popularity set to %v", c.popularity)
+	}
+}
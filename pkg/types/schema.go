@@ -0,0 +1,223 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/dd-web/pgsvk-seeder/pkg/database"
+	"github.com/lib/pq"
+)
+
+/* SCHEMA DESCRIPTION */
+/**********************/
+
+// the opforu schema. the seeder COPYs into the Copy columns of every seeded table, and the scripts
+// of the initial migration are generated from this ("schema -write"), "schema -check" fails when
+// they drift apart. adding a table means describing it
+// here and writing its seed and insert functions.
+var seed_schema = &database.Schema{
+	Tables: []*database.Table{
+		lookupTable("account_roles", "role", "VARCHAR(31)", true, true, "user", "moderator", "admin", "super"),
+		lookupTable("account_statuses", "status", "VARCHAR(31)", true, true, "active", "inactive", "suspended", "banned"),
+		{
+			Name:       "accounts",
+			PrimaryKey: "id",
+			Columns: withTimestamps(
+				&database.Column{Name: "id", Type: "INT"},
+				&database.Column{Name: "username", Type: "VARCHAR(31)", NotNull: true, Unique: true},
+				&database.Column{Name: "email", Type: "VARCHAR(255)", NotNull: true, Unique: true},
+			).append(
+				&database.Column{Name: "role_id", Type: "INT", NotNull: true, Default: "1"},
+				&database.Column{Name: "status_id", Type: "INT", NotNull: true, Default: "1"},
+			),
+			References: []*database.Reference{
+				{Column: "role_id", Table: "account_roles"},
+				{Column: "status_id", Table: "account_statuses"},
+			},
+			Copy: []string{"id", "username", "email", "status_id", "role_id", "created_at", "updated_at", "deleted_at"},
+		},
+		lookupTable("article_statuses", "status", "VARCHAR(31)", true, true, "draft", "review", "published", "archived", "retracted"),
+		{
+			Name:       "article_contents",
+			PrimaryKey: "id",
+			Columns: []*database.Column{
+				{Name: "id", Type: "INT"},
+				{Name: "content", Type: "TEXT"},
+			},
+			Copy: []string{"id", "content"},
+		},
+		{
+			Name:       "articles",
+			PrimaryKey: "id",
+			Columns: withTimestamps(
+				&database.Column{Name: "id", Type: "INT"},
+				&database.Column{Name: "author_id", Type: "INT"},
+				&database.Column{Name: "status_id", Type: "INT", Default: "1"},
+				&database.Column{Name: "content_id", Type: "INT", Unique: true},
+				&database.Column{Name: "title", Type: "VARCHAR(127)", NotNull: true},
+				&database.Column{Name: "slug", Type: "VARCHAR(63)", NotNull: true, Unique: true},
+			),
+			References: []*database.Reference{
+				{Column: "status_id", Table: "article_statuses"},
+				{Column: "author_id", Table: "accounts"},
+				{Column: "content_id", Table: "article_contents"},
+			},
+			Copy: []string{"id", "title", "slug", "content_id", "status_id", "author_id", "created_at", "updated_at", "deleted_at"},
+		},
+		{
+			Name:       "boards",
+			PrimaryKey: "id",
+			Columns: withTimestamps(
+				&database.Column{Name: "id", Type: "INT"},
+				&database.Column{Name: "title", Type: "VARCHAR(63)", NotNull: true, Unique: true},
+				&database.Column{Name: "short", Type: "VARCHAR(7)", NotNull: true, Unique: true},
+				&database.Column{Name: "description", Type: "VARCHAR(255)", NotNull: true},
+			).append(
+				&database.Column{Name: "post_count", Type: "INT", NotNull: true, Default: "1"},
+			),
+			Copy: []string{"id", "title", "short", "description", "post_count", "created_at", "updated_at"},
+		},
+		lookupTable("thread_statuses", "status", "VARCHAR(31)", true, true, "open", "locked", "closed", "archived", "removed"),
+		lookupTable("thread_roles", "role", "VARCHAR(31)", true, true, "user", "moderator", "creator"),
+		{
+			Name:       "threads",
+			PrimaryKey: "id",
+			Columns: withTimestamps(
+				&database.Column{Name: "id", Type: "INT"},
+				&database.Column{Name: "board_id", Type: "INT", NotNull: true},
+				&database.Column{Name: "status_id", Type: "INT", NotNull: true, Default: "1"},
+				&database.Column{Name: "title", Type: "VARCHAR(127)", NotNull: true},
+				&database.Column{Name: "slug", Type: "VARCHAR(127)", NotNull: true, Unique: true},
+			),
+			References: []*database.Reference{
+				{Column: "status_id", Table: "thread_statuses"},
+				{Column: "board_id", Table: "boards"},
+			},
+			Copy: []string{"id", "board_id", "title", "slug", "status_id", "created_at", "updated_at", "deleted_at"},
+		},
+		lookupTable("identity_styles", "style", "VARCHAR(63)", true, true, identityStyleRows()...),
+		lookupTable("identity_statuses", "status", "VARCHAR(31)", false, false, "active", "inactive", "suspended", "banned"),
+		{
+			Name:       "post_contents",
+			PrimaryKey: "id",
+			Columns: []*database.Column{
+				{Name: "id", Type: "INT"},
+				{Name: "content", Type: "TEXT", NotNull: true},
+			},
+			Copy: []string{"id", "content"},
+		},
+		{
+			Name:       "posts",
+			PrimaryKey: "id",
+			Columns: withTimestamps(
+				&database.Column{Name: "id", Type: "INT"},
+				&database.Column{Name: "board_id", Type: "INT", NotNull: true},
+				&database.Column{Name: "thread_id", Type: "INT", NotNull: true},
+				&database.Column{Name: "account_id", Type: "INT", NotNull: true},
+				&database.Column{Name: "content_id", Type: "INT", NotNull: true},
+				&database.Column{Name: "post_number", Type: "INT", NotNull: true},
+			),
+			Unique: [][]string{{"board_id", "post_number"}},
+			References: []*database.Reference{
+				{Column: "board_id", Table: "boards"},
+				{Column: "thread_id", Table: "threads"},
+				{Column: "content_id", Table: "post_contents"},
+				{Column: "account_id", Table: "accounts"},
+			},
+			Copy: []string{"id", "thread_id", "board_id", "content_id", "account_id", "post_number", "created_at", "updated_at", "deleted_at"},
+		},
+		{
+			Name:       "identities",
+			PrimaryKey: "id",
+			Columns: withTimestamps(
+				&database.Column{Name: "id", Type: "INT"},
+				&database.Column{Name: "board_id", Type: "INT", NotNull: true},
+				&database.Column{Name: "thread_id", Type: "INT", NotNull: true},
+				&database.Column{Name: "account_id", Type: "INT", NotNull: true},
+				&database.Column{Name: "name", Type: "VARCHAR(31)", NotNull: true},
+				&database.Column{Name: "style_id", Type: "INT", NotNull: true},
+				&database.Column{Name: "status_id", Type: "INT", NotNull: true, Default: "1"},
+				&database.Column{Name: "role_id", Type: "INT", NotNull: true, Default: "1"},
+			),
+			Unique: [][]string{{"board_id", "thread_id", "account_id"}},
+			References: []*database.Reference{
+				{Column: "role_id", Table: "thread_roles"},
+				{Column: "style_id", Table: "identity_styles"},
+				{Column: "status_id", Table: "identity_statuses"},
+				{Column: "thread_id", Table: "threads"},
+				{Column: "account_id", Table: "accounts"},
+			},
+			Copy: []string{"id", "thread_id", "account_id", "name", "style_id", "status_id", "role_id", "board_id", "created_at", "updated_at", "deleted_at"},
+		},
+		{
+			Name:       "identity_posts",
+			PrimaryKey: "id",
+			Columns: []*database.Column{
+				{Name: "id", Type: "INT"},
+				{Name: "identity_id", Type: "INT", NotNull: true},
+				{Name: "board_id", Type: "INT", NotNull: true},
+				{Name: "post_id", Type: "INT", NotNull: true},
+			},
+			References: []*database.Reference{
+				{Column: "identity_id", Table: "identities"},
+				{Column: "board_id", Table: "boards"},
+				{Column: "post_id", Table: "posts"},
+			},
+			Copy: []string{"id", "identity_id", "board_id", "post_id"},
+		},
+	},
+}
+
+// SeedSchema returns the description of the schema the seeder fills
+func SeedSchema() *database.Schema {
+	return seed_schema
+}
+
+type columns []*database.Column
+
+func (c columns) append(cols ...*database.Column) columns {
+	return append(c, cols...)
+}
+
+// the created_at, updated_at and deleted_at columns every entity table has
+func timestamps() []*database.Column {
+	return []*database.Column{
+		{Name: "created_at", Type: "TIMESTAMP", NotNull: true, Default: "NOW()"},
+		{Name: "updated_at", Type: "TIMESTAMP", NotNull: true, Default: "NOW()", Check: "updated_at >= created_at"},
+		{Name: "deleted_at", Type: "TIMESTAMP"},
+	}
+}
+
+func withTimestamps(cols ...*database.Column) columns {
+	return columns(cols).append(timestamps()...)
+}
+
+// a static table of enum values, an identity key and a single value column
+func lookupTable(name string, column string, typ string, notNull bool, unique bool, rows ...string) *database.Table {
+	return &database.Table{
+		Name:       name,
+		PrimaryKey: "id",
+		Columns: []*database.Column{
+			{Name: "id", Type: "INT"},
+			{Name: column, Type: typ, NotNull: notNull, Unique: unique},
+		},
+		Rows: rows,
+	}
+}
+
+// identity styles in the order of their ids
+func identityStyleRows() []string {
+	rows := make([]string, len(IdentityStyleID))
+	for i := range rows {
+		rows[i] = IdentityStyleID[i+1].String()
+	}
+	return rows
+}
+
+// returns the COPY statement of the given seeded table with its copy columns
+func copyIn(table string) string {
+	t := seed_schema.Table(table)
+	if t == nil || !t.Seeded() {
+		panic(fmt.Sprintf("copy into unknown seeded table %s", table))
+	}
+	return pq.CopyIn(t.Name, t.Copy...)
+}
@@ -0,0 +1,161 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/* SOFT DELETES */
+/****************/
+
+var (
+	// share of the rows of each table that are soft-deleted
+	default_delete_rates = map[string]float64{
+		"accounts":   0.02,
+		"articles":   0.05,
+		"threads":    0.03,
+		"posts":      0.04,
+		"identities": 0.01,
+	}
+
+	// rows in these statuses are soft-deleted at their own rate instead, banned accounts and removed
+	// threads are mostly gone
+	default_status_delete_rates = map[Enum]float64{
+		AccountStatusSuspended: 0.2,
+		AccountStatusBanned:    0.9,
+		ArticleStatusRetracted: 1,
+		ThreadStatusRemoved:    1,
+		IdentityStatusBanned:   0.9,
+	}
+)
+
+// sets the share, between 0 and 1, of the rows of a table that get a deleted_at. the tables are
+// accounts, articles, threads, posts and identities.
+func SeederCfgSetDeleteRate(table string, rate float64) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		rates := make(map[string]float64, len(c.deleteRates)+1)
+		for k, v := range c.deleteRates {
+			rates[k] = v
+		}
+		rates[table] = rate
+		c.deleteRates = rates
+		return c
+	}
+}
+
+// sets the share of the rows in the given status that get a deleted_at, it takes the place of the
+// rate of their table
+func SeederCfgSetStatusDeleteRate(status Enum, rate float64) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		rates := make(map[Enum]float64, len(c.statusDeleteRates)+1)
+		for k, v := range c.statusDeleteRates {
+			rates[k] = v
+		}
+		rates[status] = rate
+		c.statusDeleteRates = rates
+		return c
+	}
+}
+
+// decides whether a row of the table in the given status, nil for rows without one, is
+// soft-deleted and when. it's deleted some time after the given moment, its last activity.
+func (s *Seeder) softDelete(table string, status Enum, after time.Time) *time.Time {
+	rate := s.Cfg.deleteRates[table]
+	if r, ok := s.Cfg.statusDeleteRates[status]; ok {
+		rate = r
+	}
+
+	// drawn for every row, deleted or not, so a different rate doesn't shift what comes after
+	if s.Rand.Float64() >= rate {
+		return nil
+	}
+
+	at := s.timeAfter(after)
+	s.deleted[table]++
+	return &at
+}
+
+// deleting a row updates it
+func deletedUpdate(updated *time.Time, deleted *time.Time) *time.Time {
+	if deleted != nil && deleted.After(*updated) {
+		return deleted
+	}
+	return updated
+}
+
+// soft-deletes the accounts and threads once every post is made, so they're deleted after the last
+// thing that happened to them
+func (s *Seeder) seedDeletions() {
+	for _, a := range s.Accounts {
+		a.DeletedAt = s.softDelete("accounts", a.Status, a.lastActive())
+		a.UpdatedAt = deletedUpdate(a.UpdatedAt, a.DeletedAt)
+	}
+
+	for _, t := range s.Threads {
+		t.DeletedAt = s.softDelete("threads", t.Status, *t.UpdatedAt)
+		t.UpdatedAt = deletedUpdate(t.UpdatedAt, t.DeletedAt)
+	}
+}
+
+// names a status by its kind and value, account_status.banned, for the checkpoint and profiles
+func statusKey(status Enum) string {
+	kind := ""
+	switch status.(type) {
+	case AccountStatus:
+		kind = "account_status"
+	case ArticleStatus:
+		kind = "article_status"
+	case ThreadStatus:
+		kind = "thread_status"
+	case IdentityStatus:
+		kind = "identity_status"
+	case AccountRole:
+		kind = "account_role"
+	case ThreadRole:
+		kind = "thread_role"
+	}
+	return kind + "." + status.String()
+}
+
+func parseStatusKey(key string) (Enum, bool) {
+	kind, value, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil, false
+	}
+	status, ok := profileEnums()[kind][value]
+	return status, ok
+}
+
+// the tables rows are soft-deleted in
+func deleteTables() []string {
+	tables := make([]string, 0, len(default_delete_rates))
+	for t := range default_delete_rates {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+func checkDeleteRates(rates map[string]float64, statusRates map[Enum]float64) []error {
+	var errs []error
+
+	tables := deleteTables()
+	for table, rate := range rates {
+		if _, ok := default_delete_rates[table]; !ok {
+			errs = append(errs, fmt.Errorf("unknown soft-delete table %q, expected one of %s", table, strings.Join(tables, ", ")))
+		}
+		if rate < 0 || rate > 1 {
+			errs = append(errs, fmt.Errorf("soft-delete rate of %s must be between 0 and 1, got %v", table, rate))
+		}
+	}
+
+	for status, rate := range statusRates {
+		if rate < 0 || rate > 1 {
+			errs = append(errs, fmt.Errorf("soft-delete rate of status %s must be between 0 and 1, got %v", status, rate))
+		}
+	}
+
+	return errs
+}
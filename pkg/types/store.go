@@ -3,8 +3,12 @@ package types
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var seeder_debug_enabled = false
@@ -20,9 +24,26 @@ var (
 	pg_default_host    string = "david.local"
 	pg_default_port    string = "5432"
 	pg_default_ssl     string = "disable"
+
+	// standard libpq environment variables in the order they're applied, these override the
+	// defaults above but are themselves overridden by any configuration function passed to NewStore
+	pg_env_vars = []struct {
+		name string
+		set  func(string) PGConfigFunc
+	}{
+		{"PGHOST", PGCfgSetHost},
+		{"PGPORT", PGCfgSetPort},
+		{"PGUSER", PGCfgSetUser},
+		{"PGPASSWORD", PGCfgSetPass},
+		{"PGDATABASE", PGCfgSetDBName},
+		{"PGSSLMODE", PGCfgSetSSL},
+		{"PGSSLROOTCERT", PGCfgSetSSLRootCert},
+		{"PGSSLCERT", PGCfgSetSSLCert},
+		{"PGSSLKEY", PGCfgSetSSLKey},
+	}
 )
 
-type pgConfigFunc func(*pgConfig) *pgConfig
+type PGConfigFunc func(*pgConfig) *pgConfig
 
 type pgConfig struct {
 	connfmt  string
@@ -32,6 +53,17 @@ type pgConfig struct {
 	host     string
 	port     string
 	ssl      string
+
+	sslrootcert string
+	sslcert     string
+	sslkey      string
+
+	// any other connection parameter, like connect_timeout or application_name, added to the
+	// connection string as it is
+	params map[string]string
+
+	// set when a configuration function could not be applied, reported by NewStore
+	err error
 }
 
 func defaultPGConfig() *pgConfig {
@@ -49,65 +81,187 @@ func defaultPGConfig() *pgConfig {
 /* CONFIGURATION FUNCTIONS */
 /***************************/
 
-func PGCfgSetUser(s string) pgConfigFunc {
+func PGCfgSetUser(s string) PGConfigFunc {
 	return func(c *pgConfig) *pgConfig {
 		c.user = s
 		return c
 	}
 }
 
-func PGCfgSetPass(s string) pgConfigFunc {
+func PGCfgSetPass(s string) PGConfigFunc {
 	return func(c *pgConfig) *pgConfig {
 		c.password = s
 		return c
 	}
 }
 
-func PGCfgSetDBName(s string) pgConfigFunc {
+func PGCfgSetDBName(s string) PGConfigFunc {
 	return func(c *pgConfig) *pgConfig {
 		c.name = s
 		return c
 	}
 }
 
-func PGCfgSetHost(s string) pgConfigFunc {
+func PGCfgSetHost(s string) PGConfigFunc {
 	return func(c *pgConfig) *pgConfig {
 		c.host = s
 		return c
 	}
 }
 
-func PGCfgSetPort(s string) pgConfigFunc {
+func PGCfgSetPort(s string) PGConfigFunc {
 	return func(c *pgConfig) *pgConfig {
 		c.port = s
 		return c
 	}
 }
 
-func PGCfgSetSSL(s string) pgConfigFunc {
+func PGCfgSetSSL(s string) PGConfigFunc {
 	return func(c *pgConfig) *pgConfig {
 		c.ssl = s
 		return c
 	}
 }
 
-func PGCfgSetConnFmt(s string) pgConfigFunc {
+// sets the format of the connection string, it's given the user, password, dbname, host, port and
+// sslmode in that order. every value is already quoted, 'like this', so the format must not quote
+// them again: user=%s works, user='%s' breaks the connection string.
+func PGCfgSetConnFmt(s string) PGConfigFunc {
 	return func(c *pgConfig) *pgConfig {
 		c.connfmt = s
 		return c
 	}
 }
 
-func newPGConfig(cfg ...pgConfigFunc) *pgConfig {
+func PGCfgSetSSLRootCert(s string) PGConfigFunc {
+	return func(c *pgConfig) *pgConfig {
+		c.sslrootcert = s
+		return c
+	}
+}
+
+func PGCfgSetSSLCert(s string) PGConfigFunc {
+	return func(c *pgConfig) *pgConfig {
+		c.sslcert = s
+		return c
+	}
+}
+
+func PGCfgSetSSLKey(s string) PGConfigFunc {
+	return func(c *pgConfig) *pgConfig {
+		c.sslkey = s
+		return c
+	}
+}
+
+// sets every field present in a postgres:// (or postgresql://) url, fields missing from the url
+// are left untouched. sslmode, sslrootcert, sslcert and sslkey set their fields, any other query
+// parameter (connect_timeout, application_name, options...) is passed on to the connection string.
+func PGCfgSetURL(s string) PGConfigFunc {
+	return func(c *pgConfig) *pgConfig {
+		if err := c.parseURL(s); err != nil {
+			c.err = err
+		}
+		return c
+	}
+}
+
+func (pgc *pgConfig) parseURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid database url: %v", err)
+	}
+
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return fmt.Errorf("invalid database url scheme %q: expected postgres or postgresql", u.Scheme)
+	}
+
+	if u.User != nil {
+		if name := u.User.Username(); name != "" {
+			pgc.user = name
+		}
+		if pass, ok := u.User.Password(); ok {
+			pgc.password = pass
+		}
+	}
+
+	if host := u.Hostname(); host != "" {
+		pgc.host = host
+	}
+	if port := u.Port(); port != "" {
+		pgc.port = port
+	}
+	if name := strings.TrimPrefix(u.Path, "/"); name != "" {
+		pgc.name = name
+	}
+
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		switch key {
+		case "sslmode":
+			pgc.ssl = value
+		case "sslrootcert":
+			pgc.sslrootcert = value
+		case "sslcert":
+			pgc.sslcert = value
+		case "sslkey":
+			pgc.sslkey = value
+		default:
+			if key == "" || strings.ContainsAny(key, " \t\n='\\") {
+				return fmt.Errorf("invalid database url parameter: %q", key)
+			}
+			if pgc.params == nil {
+				pgc.params = map[string]string{}
+			}
+			pgc.params[key] = value
+		}
+	}
+
+	return nil
+}
+
+// builds the config in order of precedence: defaults, then PG* environment variables, then the
+// given configuration functions in the order they're passed
+func newPGConfig(cfg ...PGConfigFunc) *pgConfig {
 	config := defaultPGConfig()
+	for _, env := range pg_env_vars {
+		if value, ok := os.LookupEnv(env.name); ok {
+			config = env.set(value)(config)
+		}
+	}
 	for _, fn := range cfg {
 		config = fn(config)
 	}
 	return config
 }
 
+// quotes a connection string value so spaces and quotes survive parsing
+func connval(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// every value is quoted, a password from a url can hold anything
 func (pgc *pgConfig) connstr() string {
-	return fmt.Sprintf(pgc.connfmt, pgc.user, pgc.password, pgc.name, pgc.host, pgc.port, pgc.ssl)
+	str := fmt.Sprintf(pgc.connfmt, connval(pgc.user), connval(pgc.password), connval(pgc.name), connval(pgc.host), connval(pgc.port), connval(pgc.ssl))
+	if pgc.sslrootcert != "" {
+		str += " sslrootcert=" + connval(pgc.sslrootcert)
+	}
+	if pgc.sslcert != "" {
+		str += " sslcert=" + connval(pgc.sslcert)
+	}
+	if pgc.sslkey != "" {
+		str += " sslkey=" + connval(pgc.sslkey)
+	}
+
+	keys := make([]string, 0, len(pgc.params))
+	for key := range pgc.params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		str += " " + key + "=" + connval(pgc.params[key])
+	}
+	return str
 }
 
 /* STORE */
@@ -118,8 +272,12 @@ type Store struct {
 	cfg *pgConfig
 }
 
-func NewStore(cfg ...pgConfigFunc) (*Store, error) {
+func NewStore(cfg ...PGConfigFunc) (*Store, error) {
 	pgcfg := newPGConfig(cfg...)
+	if pgcfg.err != nil {
+		return nil, pgcfg.err
+	}
+
 	db, err := sql.Open("postgres", pgcfg.connstr())
 	if err != nil {
 		return nil, err
@@ -147,3 +305,22 @@ func (s *Store) Execute(query string) error {
 	}
 	return nil
 }
+
+// returns the number of rows in the given table
+func (s *Store) RowCount(table string) (int, error) {
+	var count int
+	err := s.DB.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", pq.QuoteIdentifier(table))).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// empties the given tables in a single statement
+func (s *Store) Truncate(tables ...string) error {
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = pq.QuoteIdentifier(table)
+	}
+	return s.Execute("TRUNCATE " + strings.Join(quoted, ", "))
+}
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"sort"
+	"time"
+)
+
+/* TIMELINE */
+/************/
+
+var (
+	// how far back the generated activity reaches by default
+	default_history_years = 2
+
+	// where the generated activity ends by default. it's a fixed date and not the current time so
+	// the same seed reproduces the same timestamps on every run.
+	default_window_end = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// DefaultTimeWindow is the window every generated timestamp falls in unless another is set, the 2
+// years up to 2025. boards and the fixed accounts exist from its start, everything else is created
+// somewhere inside it in an order that could have happened: accounts before their threads and
+// posts, threads before their replies and the posts of a board in the order of their post numbers.
+func DefaultTimeWindow() (time.Time, time.Time) {
+	return TimeWindowUntil(default_window_end)
+}
+
+// TimeWindowUntil is the default window moved to end at until, it reaches as far back before it
+func TimeWindowUntil(until time.Time) (time.Time, time.Time) {
+	return until.AddDate(-default_history_years, 0, 0), until
+}
+
+// sets the window the timestamps of the generated rows fall in. the same seed only reproduces the
+// same dataset with the same window.
+func SeederCfgSetTimeWindow(since time.Time, until time.Time) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.since = since.UTC().Truncate(time.Microsecond)
+		c.until = until.UTC().Truncate(time.Microsecond)
+		return c
+	}
+}
+
+// a random moment from from up to the given end, postgres keeps microseconds so nothing finer is
+// generated. an empty or inverted range returns from.
+func (s *Seeder) timeBetween(from time.Time, to time.Time) time.Time {
+	span := to.Sub(from)
+	if span <= 0 {
+		return from
+	}
+	return from.Add(time.Duration(s.Rand.Int63n(int64(span)))).Truncate(time.Microsecond)
+}
+
+// a random moment from from up to the end of the window
+func (s *Seeder) timeAfter(from time.Time) time.Time {
+	return s.timeBetween(from, s.Cfg.until)
+}
+
+// something happening on a board: a thread being opened or a reply to one
+type boardEvent struct {
+	thread  *Thread
+	at      time.Time
+	opening bool
+}
+
+// orders the events of a board by when they happen, a thread opens before anything replies to it
+func sortBoardEvents(events []boardEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].at.Equal(events[j].at) {
+			return events[i].at.Before(events[j].at)
+		}
+		return events[i].opening && !events[j].opening
+	})
+}
+
+// an account has to exist before it does anything, one active before the creation time it was
+// given is created at its first activity instead
+func (a *Account) seen(at time.Time) {
+	if at.Before(*a.CreatedAt) {
+		a.CreatedAt = &at
+	}
+	if at.After(a.active) {
+		a.active = at
+	}
+}
+
+// the last time the account did or changed anything
+func (a *Account) lastActive() time.Time {
+	if a.active.After(*a.UpdatedAt) {
+		return a.active
+	}
+	return *a.UpdatedAt
+}
+
+// the latest post of a thread is when it was last updated
+func (t *Thread) touch(at time.Time) {
+	if at.After(*t.UpdatedAt) {
+		t.UpdatedAt = &at
+	}
+}
+
+// the latest post of a board is when it was last updated
+func (b *Board) touch(at time.Time) {
+	if at.After(*b.UpdatedAt) {
+		b.UpdatedAt = &at
+	}
+}
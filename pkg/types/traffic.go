@@ -0,0 +1,240 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+/* TRAFFIC */
+/***********/
+
+// Traffic is how busy the forum is over time. threads are opened and replied to following the
+// hourly and weekly curves, and now and then a thread goes viral and gets a burst of replies in a
+// few hours. the curves are relative weights, an hour weighted 4 sees twice the posts of one
+// weighted 2.
+type Traffic struct {
+	// 24 weights, one per hour of the day in UTC starting at midnight. empty is a flat day.
+	Hourly []float64 `json:"hourly"`
+
+	// 7 weights, one per day of the week starting on sunday. empty is a flat week.
+	Weekly []float64 `json:"weekly"`
+
+	// share of threads that go viral
+	BurstRate float64 `json:"burst_rate"`
+
+	// the replies of a viral thread are multiplied by this, the extra ones make up the burst
+	BurstFactor float64 `json:"burst_factor"`
+
+	// most of a burst happens within this many hours of its start
+	BurstHours float64 `json:"burst_hours"`
+}
+
+var (
+	traffic_models = map[string]*Traffic{
+		// every moment is as busy as any other, no bursts
+		"flat": {},
+
+		// quiet nights, busy evenings and weekends, a viral thread every hundred or so
+		"forum": {
+			Hourly:      []float64{2, 1.5, 1, 0.8, 0.6, 0.6, 0.8, 1.2, 1.8, 2.4, 2.8, 3, 3.2, 3.2, 3.2, 3.4, 3.6, 4, 4.6, 5, 5, 4.6, 3.6, 2.6},
+			Weekly:      []float64{1.3, 0.9, 0.9, 0.9, 0.95, 1.05, 1.3},
+			BurstRate:   0.01,
+			BurstFactor: 8,
+			BurstHours:  6,
+		},
+
+		// office hours on weekdays, almost nothing on weekends
+		"workday": {
+			Hourly:      []float64{0.2, 0.1, 0.1, 0.1, 0.1, 0.2, 0.5, 1.5, 3.5, 5, 5, 4.5, 3.5, 4.5, 5, 4.5, 4, 3, 1.5, 1, 0.8, 0.6, 0.4, 0.3},
+			Weekly:      []float64{0.15, 1, 1, 1, 1, 0.9, 0.2},
+			BurstRate:   0.005,
+			BurstFactor: 4,
+			BurstHours:  3,
+		},
+	}
+
+	default_traffic_model = "forum"
+
+	// draws of a moment rejected by the curves before taking the last one anyway, for ranges too
+	// short to hold a busy moment
+	traffic_max_attempts = 64
+)
+
+// the names of the built-in traffic models
+func TrafficModelNames() []string {
+	names := make([]string, 0, len(traffic_models))
+	for name := range traffic_models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// looks a built-in traffic model up by its name
+func TrafficModel(name string) (*Traffic, error) {
+	t, ok := traffic_models[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown traffic model %q, expected one of %s", name, strings.Join(TrafficModelNames(), ", "))
+	}
+	return t, nil
+}
+
+// Validate checks the curves have a weight for every hour and day and the bursts are possible
+func (t *Traffic) Validate() error {
+	curves := []struct {
+		name    string
+		weights []float64
+		size    int
+	}{
+		{"hourly", t.Hourly, 24},
+		{"weekly", t.Weekly, 7},
+	}
+	for _, c := range curves {
+		if len(c.weights) == 0 {
+			continue
+		}
+		if len(c.weights) != c.size {
+			return fmt.Errorf("%s needs %d weights, got %d", c.name, c.size, len(c.weights))
+		}
+		total := 0.0
+		for i, w := range c.weights {
+			if w < 0 {
+				return fmt.Errorf("%s weight %d can't be negative, got %v", c.name, i, w)
+			}
+			total += w
+		}
+		if total == 0 {
+			return fmt.Errorf("%s needs at least one weight above 0", c.name)
+		}
+	}
+
+	if t.BurstRate < 0 || t.BurstRate > 1 {
+		return fmt.Errorf("burst_rate must be between 0 and 1, got %v", t.BurstRate)
+	}
+	if t.BurstRate > 0 && t.BurstFactor < 1 {
+		return fmt.Errorf("burst_factor must be at least 1, got %v", t.BurstFactor)
+	}
+	if t.BurstRate > 0 && t.BurstHours <= 0 {
+		return fmt.Errorf("burst_hours must be above 0, got %v", t.BurstHours)
+	}
+
+	return nil
+}
+
+// sets the traffic model threads and replies follow
+func SeederCfgSetTraffic(t *Traffic) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		c.traffic = t
+		return c
+	}
+}
+
+// sets the share of threads that go viral, keeping the rest of the traffic model. a model without
+// bursts gets the bursts of the default one, no model at all the default one.
+func SeederCfgSetBurstRate(rate float64) SeederConfigFunc {
+	return func(c *SeederConfig) *SeederConfig {
+		if c.traffic == nil {
+			c.traffic = traffic_models[default_traffic_model]
+		}
+		t := *c.traffic
+		t.BurstRate = rate
+		if t.BurstFactor == 0 && t.BurstHours == 0 {
+			t.BurstFactor = traffic_models[default_traffic_model].BurstFactor
+			t.BurstHours = traffic_models[default_traffic_model].BurstHours
+		}
+		c.traffic = &t
+		return c
+	}
+}
+
+// how busy the given moment is relative to the others
+func (t *Traffic) weight(at time.Time) float64 {
+	w := 1.0
+	if len(t.Hourly) > 0 {
+		w *= t.Hourly[at.Hour()]
+	}
+	if len(t.Weekly) > 0 {
+		w *= t.Weekly[at.Weekday()]
+	}
+	return w
+}
+
+// the weight of the busiest moment
+func (t *Traffic) peak() float64 {
+	peak := 1.0
+	for _, curve := range [][]float64{t.Hourly, t.Weekly} {
+		if len(curve) == 0 {
+			continue
+		}
+		highest := 0.0
+		for _, w := range curve {
+			if w > highest {
+				highest = w
+			}
+		}
+		peak *= highest
+	}
+	return peak
+}
+
+// a random moment from from up to the end of the window, busy moments more likely than quiet
+// ones. moments are drawn uniformly and kept with a chance of their weight to the peak.
+func (s *Seeder) trafficAfter(from time.Time) time.Time {
+	t := s.Cfg.traffic
+	peak := t.peak()
+
+	at := s.timeAfter(from)
+	for i := 1; i < traffic_max_attempts && s.Rand.Float64()*peak >= t.weight(at); i++ {
+		at = s.timeAfter(from)
+	}
+	return at
+}
+
+// n moments following the traffic curves from from up to the end of the window, earliest first
+func (s *Seeder) trafficTimesAfter(from time.Time, n int) []time.Time {
+	times := make([]time.Time, n)
+	for i := range times {
+		times[i] = s.trafficAfter(from)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// the replies of a thread that went viral, most of them shortly after the burst starts and fewer
+// the longer it goes on. none of them are past the end of the window.
+func (s *Seeder) burst(thread *Thread) []time.Time {
+	t := s.Cfg.traffic
+	if t.BurstRate == 0 || s.Rand.Float64() >= t.BurstRate {
+		return nil
+	}
+
+	start := s.trafficAfter(*thread.CreatedAt)
+	extra := int(float64(thread.replies+1)*t.BurstFactor) - (thread.replies + 1)
+
+	// exponential offsets from the start with a third of the burst as the mean, ~95% land within it
+	mean := time.Duration(t.BurstHours * float64(time.Hour) / 3)
+	times := make([]time.Time, 0, extra)
+	for i := 0; i < extra; i++ {
+		at := start.Add(burstOffset(s.Rand, mean, s.Cfg.until.Sub(start))).Truncate(time.Microsecond)
+		if at.After(s.Cfg.until) {
+			at = s.Cfg.until
+		}
+		times = append(times, at)
+	}
+	return times
+}
+
+// an exponential offset with the given mean cut off at limit. it's drawn from the exponential
+// conditioned on not passing limit, so a burst close to the end of the window still decays up to it
+// instead of piling its late replies at the end or spreading them evenly.
+func burstOffset(r *rand.Rand, mean time.Duration, limit time.Duration) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+	cut := 1 - math.Exp(-float64(limit)/float64(mean))
+	return time.Duration(-float64(mean) * math.Log1p(-r.Float64()*cut))
+}
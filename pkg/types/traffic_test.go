@@ -0,0 +1,52 @@
+package types
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBurstOffsetDecaysUpToTheLimit(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	mean, limit := time.Hour, 2*time.Hour
+
+	const n = 100_000
+	firstHour, lastQuarter := 0, 0
+	for i := 0; i < n; i++ {
+		offset := burstOffset(r, mean, limit)
+		if offset < 0 || offset > limit {
+			t.Fatalf("offset %v outside 0 to %v", offset, limit)
+		}
+		if offset < time.Hour {
+			firstHour++
+		}
+		if offset >= 90*time.Minute {
+			lastQuarter++
+		}
+	}
+
+	// shares of the exponential with a mean of an hour conditioned on the first two hours
+	cut := 1 - math.Exp(-2)
+	shares := []struct {
+		name      string
+		got, want float64
+	}{
+		{"first hour", float64(firstHour) / n, (1 - math.Exp(-1)) / cut},
+		{"last half hour", float64(lastQuarter) / n, (math.Exp(-1.5) - math.Exp(-2)) / cut},
+	}
+	for _, s := range shares {
+		if math.Abs(s.got-s.want) > 0.01 {
+			t.Errorf("%s holds %.3f of the offsets, expected %.3f", s.name, s.got, s.want)
+		}
+	}
+}
+
+func TestBurstOffsetWithoutRoom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, limit := range []time.Duration{0, -time.Hour} {
+		if offset := burstOffset(r, time.Hour, limit); offset != 0 {
+			t.Errorf("offset %v with a limit of %v, expected 0", offset, limit)
+		}
+	}
+}